@@ -0,0 +1,95 @@
+// Package dh is finite field Diffie-Hellman
+package dh
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/krehermann/go-cryptopals/sha1"
+)
+
+const nistPrimeHex = "ffffffffffffffffc90fdaa22168c234c4c6628b80dc1cd129024" +
+	"e088a67cc74020bbea63b139b22514a08798e3404ddef9519b3cd" +
+	"3a431b302b0a6df25f14374fe1356d6d51c245e485b576625e7ec" +
+	"6f44c42e9a637ed6b0bff5cb6f406b7edee386bfb5a899fa5ae9f" +
+	"24117c4b1fe649286651ece45b3dc2007cb8a163bf0598da48361" +
+	"c55d39a69163fa8fd24cf5f83655d23dca3ad961c62f356208552" +
+	"bb9ed529077096966d670c354e4abc9804f1746c08ca237327fff" +
+	"fffffffffffff"
+
+var ErrInvalidPublicKey = errors.New("invalid public key")
+
+// Group is the prime modulus and generator both parties agree on
+type Group struct {
+	P *big.Int
+	G *big.Int
+}
+
+// NISTGroup is the 1536 bit MODP group used by cryptopals, with g = 2
+func NISTGroup() *Group {
+	p, ok := new(big.Int).SetString(nistPrimeHex, 16)
+	if !ok {
+		panic("bad nist prime")
+	}
+	return &Group{
+		P: p,
+		G: big.NewInt(2),
+	}
+}
+
+type KeyPair struct {
+	Group   *Group
+	Private *big.Int
+	Public  *big.Int
+}
+
+// GenerateKey picks a random private key in [1, p-1) and computes g^private mod p
+func (g *Group) GenerateKey() (*KeyPair, error) {
+	max := new(big.Int).Sub(g.P, big.NewInt(2))
+	priv, err := rand.Int(rand.Reader, max)
+	if err != nil {
+		return nil, err
+	}
+	priv.Add(priv, big.NewInt(1))
+	return &KeyPair{
+		Group:   g,
+		Private: priv,
+		Public:  new(big.Int).Exp(g.G, priv, g.P),
+	}, nil
+}
+
+// ValidatePublic rejects public values that force the shared secret into a
+// tiny subgroup: anything <= 1 or >= p-1
+func (g *Group) ValidatePublic(pub *big.Int) error {
+	if pub == nil {
+		return fmt.Errorf("%w: nil", ErrInvalidPublicKey)
+	}
+	pMinus1 := new(big.Int).Sub(g.P, big.NewInt(1))
+	if pub.Cmp(big.NewInt(1)) <= 0 || pub.Cmp(pMinus1) >= 0 {
+		return fmt.Errorf("%w: %s", ErrInvalidPublicKey, pub.Text(16))
+	}
+	return nil
+}
+
+// SharedSecret validates the peer's public value and computes peer^private mod p
+func (k *KeyPair) SharedSecret(peer *big.Int) (*big.Int, error) {
+	if err := k.Group.ValidatePublic(peer); err != nil {
+		return nil, err
+	}
+	return k.UncheckedSharedSecret(peer), nil
+}
+
+// UncheckedSharedSecret is SharedSecret without validation, as a naive
+// implementation would do it
+func (k *KeyPair) UncheckedSharedSecret(peer *big.Int) *big.Int {
+	return new(big.Int).Exp(peer, k.Private, k.Group.P)
+}
+
+// AESKey derives a 16 byte AES key from a shared secret: the first 16 bytes
+// of SHA1(secret)
+func AESKey(secret *big.Int) []byte {
+	sum := sha1.Sum(secret.Bytes())
+	return sum[:16]
+}
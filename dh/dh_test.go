@@ -0,0 +1,85 @@
+package dh
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/krehermann/go-cryptopals/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// set 5 challenge 33
+func TestSharedSecret(t *testing.T) {
+	t.Run("small group", func(t *testing.T) {
+		g := &Group{P: big.NewInt(37), G: big.NewInt(5)}
+		a, err := g.GenerateKey()
+		require.NoError(t, err)
+		b, err := g.GenerateKey()
+		require.NoError(t, err)
+		assert.Equal(t, a.UncheckedSharedSecret(b.Public), b.UncheckedSharedSecret(a.Public))
+	})
+
+	t.Run("nist", func(t *testing.T) {
+		g := NISTGroup()
+		assert.Equal(t, 1536, g.P.BitLen())
+
+		a, err := g.GenerateKey()
+		require.NoError(t, err)
+		b, err := g.GenerateKey()
+		require.NoError(t, err)
+
+		sa, err := a.SharedSecret(b.Public)
+		require.NoError(t, err)
+		sb, err := b.SharedSecret(a.Public)
+		require.NoError(t, err)
+		assert.Equal(t, sa, sb)
+
+		// the derived key drives AES directly
+		msg := []byte("hello bob")
+		ca, err := utils.NewAES(AESKey(sa), utils.AESCBC)
+		require.NoError(t, err)
+		enc, err := ca.Encrypt(msg)
+		require.NoError(t, err)
+		cb, err := utils.NewAES(AESKey(sb), utils.AESCBC)
+		require.NoError(t, err)
+		got, err := cb.Decrypt(enc)
+		require.NoError(t, err)
+		assert.Equal(t, msg, got)
+	})
+}
+
+func TestGroup_ValidatePublic(t *testing.T) {
+	g := NISTGroup()
+	pMinus1 := new(big.Int).Sub(g.P, big.NewInt(1))
+
+	tests := []struct {
+		name    string
+		pub     *big.Int
+		wantErr bool
+	}{
+		{name: "0", pub: big.NewInt(0), wantErr: true},
+		{name: "1", pub: big.NewInt(1), wantErr: true},
+		{name: "p-1", pub: pMinus1, wantErr: true},
+		{name: "p", pub: g.P, wantErr: true},
+		{name: "negative", pub: big.NewInt(-2), wantErr: true},
+		{name: "nil", pub: nil, wantErr: true},
+		{name: "2", pub: big.NewInt(2)},
+		{name: "p-2", pub: new(big.Int).Sub(g.P, big.NewInt(2))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := g.ValidatePublic(tt.pub)
+			if tt.wantErr {
+				require.ErrorIs(t, err, ErrInvalidPublicKey)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+
+	k, err := g.GenerateKey()
+	require.NoError(t, err)
+	_, err = k.SharedSecret(g.P)
+	require.ErrorIs(t, err, ErrInvalidPublicKey)
+}
@@ -0,0 +1,415 @@
+package dh
+
+import (
+	"bytes"
+	"crypto/rand"
+	"fmt"
+	"math/big"
+
+	"github.com/krehermann/go-cryptopals/utils"
+)
+
+type MessageKind int
+
+const (
+	// Negotiate proposes the group, P and G
+	Negotiate MessageKind = iota
+	// Ack accepts the group, P and G
+	Ack
+	// PublicKey carries Public
+	PublicKey
+	// Data carries Data, AES-CBC ciphertext || iv
+	Data
+)
+
+type Message struct {
+	From   string
+	To     string
+	Kind   MessageKind
+	P      *big.Int
+	G      *big.Int
+	Public *big.Int
+	Data   []byte
+}
+
+// Actor is a party to a protocol. Handle returns the messages it sends in response
+type Actor interface {
+	Name() string
+	Handle(msg Message) ([]Message, error)
+}
+
+// maxDeliveries stops replay loops from running forever
+const maxDeliveries = 1000
+
+// Bus delivers messages between actors in process. if Mallory is set every
+// message passes through her first
+type Bus struct {
+	actors  map[string]Actor
+	Mallory *Mallory
+	// Log is every message delivered, after Mallory has had her way
+	Log []Message
+}
+
+func NewBus(actors ...Actor) *Bus {
+	b := &Bus{actors: make(map[string]Actor)}
+	for _, a := range actors {
+		b.actors[a.Name()] = a
+	}
+	return b
+}
+
+// Run delivers msgs, and everything sent in response, until no messages remain
+func (b *Bus) Run(msgs ...Message) error {
+	queue := append([]Message{}, msgs...)
+	for len(queue) > 0 {
+		msg := queue[0]
+		queue = queue[1:]
+
+		deliver := []Message{msg}
+		if b.Mallory != nil {
+			deliver = b.Mallory.Intercept(msg)
+		}
+		for _, m := range deliver {
+			if len(b.Log) >= maxDeliveries {
+				return fmt.Errorf("more than %d messages delivered", maxDeliveries)
+			}
+			to, ok := b.actors[m.To]
+			if !ok {
+				return fmt.Errorf("no actor %q", m.To)
+			}
+			b.Log = append(b.Log, m)
+			replies, err := to.Handle(m)
+			if err != nil {
+				return fmt.Errorf("%s handling message from %s: %w", m.To, m.From, err)
+			}
+			queue = append(queue, replies...)
+		}
+	}
+	return nil
+}
+
+// echoParty is the state both ends of the echo protocol share
+type echoParty struct {
+	name  string
+	peer  string
+	group *Group
+	key   *KeyPair
+	// secret is the AES key derived from the shared secret
+	secret []byte
+	// Validate checks the peer's public value. off, like a naive implementation
+	Validate bool
+}
+
+func (p *echoParty) Name() string {
+	return p.name
+}
+
+func (p *echoParty) deriveKey(peer *big.Int) error {
+	var s *big.Int
+	if p.Validate {
+		var err error
+		s, err = p.key.SharedSecret(peer)
+		if err != nil {
+			return err
+		}
+	} else {
+		s = p.key.UncheckedSharedSecret(peer)
+	}
+	p.secret = AESKey(s)
+	return nil
+}
+
+func (p *echoParty) send(kind MessageKind) Message {
+	return Message{From: p.name, To: p.peer, Kind: kind}
+}
+
+// EchoClient is Alice. she negotiates a group, exchanges keys, sends
+// an encrypted message and checks that it comes back
+type EchoClient struct {
+	echoParty
+	msg []byte
+	// Echo is the decrypted reply
+	Echo []byte
+}
+
+func NewEchoClient(name, peer string, group *Group, msg []byte) *EchoClient {
+	return &EchoClient{
+		echoParty: echoParty{name: name, peer: peer, group: group},
+		msg:       msg,
+	}
+}
+
+// Start is the first message of the protocol
+func (c *EchoClient) Start() Message {
+	m := c.send(Negotiate)
+	m.P, m.G = c.group.P, c.group.G
+	return m
+}
+
+func (c *EchoClient) Handle(msg Message) ([]Message, error) {
+	switch msg.Kind {
+	case Ack:
+		// use whatever group the server acknowledged
+		c.group = &Group{P: msg.P, G: msg.G}
+		k, err := c.group.GenerateKey()
+		if err != nil {
+			return nil, err
+		}
+		c.key = k
+		m := c.send(PublicKey)
+		m.Public = k.Public
+		return []Message{m}, nil
+	case PublicKey:
+		if err := c.deriveKey(msg.Public); err != nil {
+			return nil, err
+		}
+		data, err := encryptEcho(c.secret, c.msg)
+		if err != nil {
+			return nil, err
+		}
+		m := c.send(Data)
+		m.Data = data
+		return []Message{m}, nil
+	case Data:
+		txt, err := decryptEcho(c.secret, msg.Data)
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(txt, c.msg) {
+			return nil, fmt.Errorf("echo mismatch: sent %q got %q", c.msg, txt)
+		}
+		c.Echo = txt
+		return nil, nil
+	}
+	return nil, fmt.Errorf("unexpected message kind %d", msg.Kind)
+}
+
+// EchoBot is Bob. he accepts any group, and decrypts and re-encrypts
+// whatever he is sent under a fresh iv
+type EchoBot struct {
+	echoParty
+}
+
+func NewEchoBot(name, peer string) *EchoBot {
+	return &EchoBot{echoParty: echoParty{name: name, peer: peer}}
+}
+
+func (b *EchoBot) Handle(msg Message) ([]Message, error) {
+	switch msg.Kind {
+	case Negotiate:
+		b.group = &Group{P: msg.P, G: msg.G}
+		m := b.send(Ack)
+		m.P, m.G = msg.P, msg.G
+		return []Message{m}, nil
+	case PublicKey:
+		k, err := b.group.GenerateKey()
+		if err != nil {
+			return nil, err
+		}
+		b.key = k
+		if err := b.deriveKey(msg.Public); err != nil {
+			return nil, err
+		}
+		m := b.send(PublicKey)
+		m.Public = k.Public
+		return []Message{m}, nil
+	case Data:
+		txt, err := decryptEcho(b.secret, msg.Data)
+		if err != nil {
+			return nil, err
+		}
+		data, err := encryptEcho(b.secret, txt)
+		if err != nil {
+			return nil, err
+		}
+		m := b.send(Data)
+		m.Data = data
+		return []Message{m}, nil
+	}
+	return nil, fmt.Errorf("unexpected message kind %d", msg.Kind)
+}
+
+func encryptEcho(key, msg []byte) ([]byte, error) {
+	iv, err := randomIV()
+	if err != nil {
+		return nil, err
+	}
+	a, err := utils.NewAES(key, utils.AESCBC, utils.WithIV(iv))
+	if err != nil {
+		return nil, err
+	}
+	enc, err := a.Encrypt(msg)
+	if err != nil {
+		return nil, err
+	}
+	return append(enc, iv...), nil
+}
+
+func decryptEcho(key, data []byte) ([]byte, error) {
+	if len(data) < 2*ivLen {
+		return nil, fmt.Errorf("data too short: %d bytes", len(data))
+	}
+	enc, iv := data[:len(data)-ivLen], data[len(data)-ivLen:]
+	a, err := utils.NewAES(key, utils.AESCBC, utils.WithIV(iv))
+	if err != nil {
+		return nil, err
+	}
+	return a.Decrypt(enc)
+}
+
+const ivLen = 16
+
+func randomIV() ([]byte, error) {
+	iv := make([]byte, ivLen)
+	if _, err := rand.Read(iv); err != nil {
+		return nil, err
+	}
+	return iv, nil
+}
+
+// Mallory sits on the Bus. Rewrite, Drop and Replay are optional hooks;
+// Secrets lists the shared secrets she expects, given the group she has
+// seen, and Data messages she can decrypt with them end up in Recovered
+type Mallory struct {
+	// Rewrite may modify a message in flight
+	Rewrite func(group *Group, msg *Message)
+	// Drop discards a message
+	Drop func(msg Message) bool
+	// Replay is the number of extra copies of a message to deliver
+	Replay func(msg Message) int
+	// Secrets are the candidate shared secrets
+	Secrets func(group *Group) []*big.Int
+
+	group *Group
+	// unechoed holds, per candidate secret, what each Data message decrypted
+	// to until the same plaintext comes back the other way
+	unechoed map[string][]echoed
+	// Recovered is the plaintext of every Data message Mallory could decrypt
+	Recovered [][]byte
+}
+
+type echoed struct {
+	from string
+	txt  []byte
+}
+
+// Intercept returns the messages to deliver in place of msg
+func (m *Mallory) Intercept(msg Message) []Message {
+	if msg.Kind == Negotiate {
+		m.group = &Group{P: msg.P, G: msg.G}
+	}
+	if m.Rewrite != nil {
+		m.Rewrite(m.group, &msg)
+	}
+	if msg.Kind == Data && m.Secrets != nil {
+		m.recover(msg)
+	}
+	if m.Drop != nil && m.Drop(msg) {
+		return nil
+	}
+	out := []Message{msg}
+	if m.Replay != nil {
+		for i := 0; i < m.Replay(msg); i++ {
+			out = append(out, msg)
+		}
+	}
+	return out
+}
+
+// recover decrypts msg under every candidate secret. a wrong key still
+// gives valid padding about 1 time in 256, so a plaintext only counts once
+// it has been echoed: a candidate must decrypt a message from each side to
+// the same plaintext, and no other candidate may do the same
+func (m *Mallory) recover(msg Message) {
+	if m.unechoed == nil {
+		m.unechoed = make(map[string][]echoed)
+	}
+	var (
+		matches int
+		pair    [][]byte
+	)
+	for _, s := range m.Secrets(m.group) {
+		txt, err := decryptEcho(AESKey(s), msg.Data)
+		if err != nil {
+			continue
+		}
+		k := s.String()
+		prior := m.unechoed[k]
+		idx := -1
+		for i, e := range prior {
+			if e.from != msg.From && bytes.Equal(e.txt, txt) {
+				idx = i
+				break
+			}
+		}
+		if idx < 0 {
+			m.unechoed[k] = append(prior, echoed{from: msg.From, txt: txt})
+			continue
+		}
+		m.unechoed[k] = append(prior[:idx], prior[idx+1:]...)
+		matches++
+		pair = [][]byte{prior[idx].txt, txt}
+	}
+	if matches == 1 {
+		m.Recovered = append(m.Recovered, pair...)
+	}
+}
+
+// NewParameterInjection replaces both public keys with p, so both sides
+// compute a shared secret of p^x mod p = 0
+func NewParameterInjection() *Mallory {
+	return &Mallory{
+		Rewrite: func(group *Group, msg *Message) {
+			if msg.Kind == PublicKey && group != nil {
+				msg.Public = new(big.Int).Set(group.P)
+			}
+		},
+		Secrets: func(*Group) []*big.Int {
+			return []*big.Int{big.NewInt(0)}
+		},
+	}
+}
+
+// MaliciousG picks the generator Mallory negotiates
+type MaliciousG int
+
+const (
+	// GOne makes every public key and the shared secret 1
+	GOne MaliciousG = iota
+	// GP makes every public key and the shared secret 0
+	GP
+	// GPMinus1 makes the shared secret 1 or p-1
+	GPMinus1
+)
+
+// NewMaliciousG rewrites g during negotiation, in both directions, so the
+// shared secret is one of a few known values
+func NewMaliciousG(choice MaliciousG) *Mallory {
+	g := func(group *Group) *big.Int {
+		switch choice {
+		case GOne:
+			return big.NewInt(1)
+		case GP:
+			return new(big.Int).Set(group.P)
+		default:
+			return new(big.Int).Sub(group.P, big.NewInt(1))
+		}
+	}
+	return &Mallory{
+		Rewrite: func(group *Group, msg *Message) {
+			if (msg.Kind == Negotiate || msg.Kind == Ack) && group != nil {
+				msg.G = g(group)
+			}
+		},
+		Secrets: func(group *Group) []*big.Int {
+			switch choice {
+			case GOne:
+				return []*big.Int{big.NewInt(1)}
+			case GP:
+				return []*big.Int{big.NewInt(0)}
+			default:
+				return []*big.Int{big.NewInt(1), new(big.Int).Sub(group.P, big.NewInt(1))}
+			}
+		},
+	}
+}
@@ -0,0 +1,119 @@
+package dh
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newEchoSession(msg []byte) (*EchoClient, *EchoBot, *Bus) {
+	alice := NewEchoClient("alice", "bob", NISTGroup(), msg)
+	bob := NewEchoBot("bob", "alice")
+	return alice, bob, NewBus(alice, bob)
+}
+
+func TestEcho(t *testing.T) {
+	msg := []byte("the eagle has landed")
+
+	t.Run("honest", func(t *testing.T) {
+		alice, _, bus := newEchoSession(msg)
+		require.NoError(t, bus.Run(alice.Start()))
+		assert.Equal(t, msg, alice.Echo)
+		assert.Len(t, bus.Log, 6)
+	})
+
+	t.Run("passive mallory learns nothing", func(t *testing.T) {
+		alice, _, bus := newEchoSession(msg)
+		bus.Mallory = &Mallory{
+			Secrets: func(*Group) []*big.Int { return []*big.Int{big.NewInt(0), big.NewInt(1)} },
+		}
+		require.NoError(t, bus.Run(alice.Start()))
+		assert.Equal(t, msg, alice.Echo)
+		assert.Empty(t, bus.Mallory.Recovered)
+	})
+
+	t.Run("drop", func(t *testing.T) {
+		alice, _, bus := newEchoSession(msg)
+		bus.Mallory = &Mallory{
+			Drop: func(m Message) bool { return m.Kind == Data && m.From == "bob" },
+		}
+		require.NoError(t, bus.Run(alice.Start()))
+		assert.Nil(t, alice.Echo)
+	})
+
+	t.Run("replay", func(t *testing.T) {
+		alice, _, bus := newEchoSession(msg)
+		bus.Mallory = &Mallory{
+			Replay: func(m Message) int {
+				if m.Kind == Data && m.From == "alice" {
+					return 2
+				}
+				return 0
+			},
+		}
+		require.NoError(t, bus.Run(alice.Start()))
+		assert.Equal(t, msg, alice.Echo)
+		// 3 copies of alice's data, and 3 echos
+		assert.Len(t, bus.Log, 10)
+	})
+}
+
+// set 5 challenges 34 and 35
+func TestMallory(t *testing.T) {
+	msg := []byte("the eagle has landed")
+
+	tests := []struct {
+		name    string
+		mallory func() *Mallory
+	}{
+		{name: "parameter injection", mallory: NewParameterInjection},
+		{name: "g=1", mallory: func() *Mallory { return NewMaliciousG(GOne) }},
+		{name: "g=p", mallory: func() *Mallory { return NewMaliciousG(GP) }},
+		{name: "g=p-1", mallory: func() *Mallory { return NewMaliciousG(GPMinus1) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			alice, _, bus := newEchoSession(msg)
+			bus.Mallory = tt.mallory()
+			require.NoError(t, bus.Run(alice.Start()))
+
+			// alice and bob are none the wiser
+			assert.Equal(t, msg, alice.Echo)
+			// and mallory read both directions
+			require.Len(t, bus.Mallory.Recovered, 2)
+			for _, got := range bus.Mallory.Recovered {
+				assert.Equal(t, msg, got)
+			}
+		})
+
+		t.Run(tt.name+" validated", func(t *testing.T) {
+			alice, bob, bus := newEchoSession(msg)
+			alice.Validate = true
+			bob.Validate = true
+			bus.Mallory = tt.mallory()
+			require.ErrorIs(t, bus.Run(alice.Start()), ErrInvalidPublicKey)
+			assert.Empty(t, bus.Mallory.Recovered)
+		})
+	}
+}
+
+func TestMallory_RequiresEcho(t *testing.T) {
+	secret := big.NewInt(1)
+	m := &Mallory{Secrets: func(*Group) []*big.Int { return []*big.Int{big.NewInt(0), secret} }}
+	send := func(from string, txt string) {
+		data, err := encryptEcho(AESKey(secret), []byte(txt))
+		require.NoError(t, err)
+		m.Intercept(Message{From: from, Kind: Data, Data: data})
+	}
+
+	// decrypting is not enough, the plaintext has to come back the other way
+	send("alice", "ping")
+	send("alice", "ping")
+	send("bob", "pong")
+	assert.Empty(t, m.Recovered)
+
+	send("bob", "ping")
+	assert.Equal(t, [][]byte{[]byte("ping"), []byte("ping")}, m.Recovered)
+}
@@ -0,0 +1,263 @@
+// Package dsa is DSA over the cryptopals parameters, and the ways it breaks
+// when nonces or parameters are not what they should be
+package dsa
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/krehermann/go-cryptopals/rsa"
+	"github.com/krehermann/go-cryptopals/sha1"
+)
+
+var (
+	ErrKeyNotFound = errors.New("private key not found")
+	ErrSignFailed  = errors.New("no nonce gave a nonzero signature")
+)
+
+// maxSignAttempts bounds Sign, which never succeeds with parameters like g = 0
+const maxSignAttempts = 100
+
+var (
+	zero = big.NewInt(0)
+	one  = big.NewInt(1)
+)
+
+type Params struct {
+	P *big.Int
+	Q *big.Int
+	G *big.Int
+}
+
+func mustHex(s string) *big.Int {
+	n, ok := new(big.Int).SetString(s, 16)
+	if !ok {
+		panic("bad hex " + s)
+	}
+	return n
+}
+
+// CryptopalsParams are the 1024 bit p, 160 bit q and g from set 6
+func CryptopalsParams() *Params {
+	return &Params{
+		P: mustHex("800000000000000089e1855218a0e7dac38136ffafa72eda7" +
+			"859f2171e25e65eac698c1702578b07dc2a1076da241c76c6" +
+			"2d374d8389ea5aeffd3226a0530cc565f3bf6b50929139ebe" +
+			"ac04f48c3c84afb796d61e5a4f9a8fda812ab59494232c7d2" +
+			"b4deb50aa18ee9e132bfa85ac4374d7f9091abc3d015efc87" +
+			"1a584471bb1"),
+		Q: mustHex("f4f47f05794b256174bba6e9b396a7707e563c5b"),
+		G: mustHex("5958c9d3898b224b12672c0b98e06c60df923cb8bc999d119" +
+			"458fef538b8fa4046c8db53039db620c094c9fa077ef389b5" +
+			"322a559946a71903f990f1f7e0e025e2d7f7cf494aff1a047" +
+			"0f5b64c36b625a097f1651fe775323556fe00b3608c887892" +
+			"878480e99041be601a62166ca6894bdd41a7054ec89f756ba" +
+			"9fc95302291"),
+	}
+}
+
+type PublicKey struct {
+	*Params
+	Y *big.Int
+}
+
+type PrivateKey struct {
+	PublicKey
+	X *big.Int
+}
+
+type Signature struct {
+	R *big.Int
+	S *big.Int
+}
+
+// GenerateKey picks x in [1, q) and y = g^x mod p
+func (p *Params) GenerateKey() (*PrivateKey, error) {
+	x, err := randomScalar(p.Q)
+	if err != nil {
+		return nil, err
+	}
+	return p.privateKey(x), nil
+}
+
+func (p *Params) privateKey(x *big.Int) *PrivateKey {
+	return &PrivateKey{
+		PublicKey: PublicKey{Params: p, Y: new(big.Int).Exp(p.G, x, p.P)},
+		X:         x,
+	}
+}
+
+// Hash is SHA1(msg) as an integer
+func Hash(msg []byte) *big.Int {
+	sum := sha1.Sum(msg)
+	return new(big.Int).SetBytes(sum[:])
+}
+
+// Fingerprint is the SHA-1 of the hex encoding of x, the way set 6 publishes keys
+func Fingerprint(x *big.Int) string {
+	sum := sha1.Sum([]byte(x.Text(16)))
+	return hex.EncodeToString(sum[:])
+}
+
+// Sign picks a fresh random nonce, retrying if r or s come out zero
+func (k *PrivateKey) Sign(msg []byte) (*Signature, error) {
+	for i := 0; i < maxSignAttempts; i++ {
+		nonce, err := randomScalar(k.Q)
+		if err != nil {
+			return nil, err
+		}
+		sig, err := k.SignWithNonce(msg, nonce)
+		if err != nil {
+			return nil, err
+		}
+		if sig.R.Sign() != 0 && sig.S.Sign() != 0 {
+			return sig, nil
+		}
+	}
+	return nil, ErrSignFailed
+}
+
+// SignWithNonce signs with the given nonce k. it does not reject r = 0, which
+// is what lets g = 0 produce signatures
+func (k *PrivateKey) SignWithNonce(msg []byte, nonce *big.Int) (*Signature, error) {
+	kInv, err := rsa.InvMod(nonce, k.Q)
+	if err != nil {
+		return nil, err
+	}
+	// r = (g^k mod p) mod q
+	r := new(big.Int).Exp(k.G, nonce, k.P)
+	r.Mod(r, k.Q)
+	// s = k^-1 (H(m) + x r) mod q
+	s := new(big.Int).Mul(k.X, r)
+	s.Add(s, Hash(msg))
+	s.Mul(s, kInv)
+	s.Mod(s, k.Q)
+	return &Signature{R: r, S: s}, nil
+}
+
+// Verify checks 0 < r, s < q before verifying
+func (k *PublicKey) Verify(msg []byte, sig *Signature) bool {
+	if sig.R.Sign() <= 0 || sig.R.Cmp(k.Q) >= 0 || sig.S.Sign() <= 0 || sig.S.Cmp(k.Q) >= 0 {
+		return false
+	}
+	return k.VerifyUnchecked(msg, sig)
+}
+
+// VerifyUnchecked skips the range checks on r and s
+func (k *PublicKey) VerifyUnchecked(msg []byte, sig *Signature) bool {
+	w, err := rsa.InvMod(sig.S, k.Q)
+	if err != nil {
+		return false
+	}
+	u1 := new(big.Int).Mul(Hash(msg), w)
+	u1.Mod(u1, k.Q)
+	u2 := new(big.Int).Mul(sig.R, w)
+	u2.Mod(u2, k.Q)
+
+	// v = ((g^u1 * y^u2) mod p) mod q
+	v := new(big.Int).Exp(k.G, u1, k.P)
+	v.Mul(v, new(big.Int).Exp(k.Y, u2, k.P))
+	v.Mod(v, k.P)
+	v.Mod(v, k.Q)
+	return v.Cmp(sig.R) == 0
+}
+
+// KeyFromNonce solves s = k^-1 (H + x r) for x: x = (s k - H) / r mod q
+func KeyFromNonce(params *Params, h *big.Int, sig *Signature, nonce *big.Int) (*big.Int, error) {
+	rInv, err := rsa.InvMod(sig.R, params.Q)
+	if err != nil {
+		return nil, err
+	}
+	x := new(big.Int).Mul(sig.S, nonce)
+	x.Sub(x, h)
+	x.Mul(x, rInv)
+	return x.Mod(x, params.Q), nil
+}
+
+// RecoverKeySmallNonce tries every nonce in [0, maxNonce] and returns the
+// private key whose public key is pub
+func RecoverKeySmallNonce(pub *PublicKey, h *big.Int, sig *Signature, maxNonce int64) (*PrivateKey, error) {
+	gk := big.NewInt(1)
+	r := new(big.Int)
+	for nonce := int64(0); nonce <= maxNonce; nonce++ {
+		// the nonce is right when (g^k mod p) mod q = r
+		if r.Mod(gk, pub.Q).Cmp(sig.R) == 0 {
+			x, err := KeyFromNonce(pub.Params, h, sig, big.NewInt(nonce))
+			if err == nil && new(big.Int).Exp(pub.G, x, pub.P).Cmp(pub.Y) == 0 {
+				return pub.privateKey(x), nil
+			}
+		}
+		gk.Mul(gk, pub.G)
+		gk.Mod(gk, pub.P)
+	}
+	return nil, fmt.Errorf("%w: nonce not in [0, %d]", ErrKeyNotFound, maxNonce)
+}
+
+// SignedMessage is a message hash and its signature
+type SignedMessage struct {
+	H   *big.Int
+	Sig *Signature
+}
+
+// RecoverKeyRepeatedNonce finds two signatures sharing r, and so a nonce:
+// k = (H1 - H2) / (s1 - s2) mod q
+func RecoverKeyRepeatedNonce(pub *PublicKey, msgs []SignedMessage) (*PrivateKey, error) {
+	for i := range msgs {
+		for j := i + 1; j < len(msgs); j++ {
+			a, b := msgs[i], msgs[j]
+			if a.Sig.R.Cmp(b.Sig.R) != 0 {
+				continue
+			}
+			ds := new(big.Int).Sub(a.Sig.S, b.Sig.S)
+			ds.Mod(ds, pub.Q)
+			dsInv, err := rsa.InvMod(ds, pub.Q)
+			if err != nil {
+				continue
+			}
+			nonce := new(big.Int).Sub(a.H, b.H)
+			nonce.Mul(nonce, dsInv)
+			nonce.Mod(nonce, pub.Q)
+
+			x, err := KeyFromNonce(pub.Params, a.H, a.Sig, nonce)
+			if err != nil {
+				continue
+			}
+			if new(big.Int).Exp(pub.G, x, pub.P).Cmp(pub.Y) == 0 {
+				return pub.privateKey(x), nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("%w: no repeated nonce", ErrKeyNotFound)
+}
+
+// ZeroGSignature verifies for every message under VerifyUnchecked when g = 0:
+// v = 0^u1 * y^0 mod p mod q = 0 = r
+func ZeroGSignature() *Signature {
+	return &Signature{R: new(big.Int).Set(zero), S: big.NewInt(1)}
+}
+
+// MagicSignature verifies for every message when g = p + 1, since g^u1 = 1:
+// r = (y^z mod p) mod q, s = r / z mod q for any z
+func MagicSignature(pub *PublicKey, z *big.Int) (*Signature, error) {
+	zInv, err := rsa.InvMod(z, pub.Q)
+	if err != nil {
+		return nil, err
+	}
+	r := new(big.Int).Exp(pub.Y, z, pub.P)
+	r.Mod(r, pub.Q)
+	s := new(big.Int).Mul(r, zInv)
+	s.Mod(s, pub.Q)
+	return &Signature{R: r, S: s}, nil
+}
+
+// randomScalar is in [1, q)
+func randomScalar(q *big.Int) (*big.Int, error) {
+	n, err := rand.Int(rand.Reader, new(big.Int).Sub(q, one))
+	if err != nil {
+		return nil, err
+	}
+	return n.Add(n, one), nil
+}
@@ -0,0 +1,165 @@
+package dsa
+
+import (
+	"math/big"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignVerify(t *testing.T) {
+	params := CryptopalsParams()
+	k, err := params.GenerateKey()
+	require.NoError(t, err)
+	msg := []byte("hi mom")
+
+	sig, err := k.Sign(msg)
+	require.NoError(t, err)
+	assert.True(t, k.Verify(msg, sig))
+	assert.False(t, k.Verify([]byte("hi dad"), sig))
+
+	bad := &Signature{R: sig.R, S: new(big.Int).Add(sig.S, big.NewInt(1))}
+	assert.False(t, k.Verify(msg, bad))
+	assert.False(t, k.Verify(msg, &Signature{R: big.NewInt(0), S: sig.S}))
+	assert.False(t, k.Verify(msg, &Signature{R: sig.R, S: params.Q}))
+}
+
+// set 6 challenge 43
+func TestRecoverKeySmallNonce(t *testing.T) {
+	params := CryptopalsParams()
+	pub := &PublicKey{
+		Params: params,
+		Y: mustHex("84ad4719d044495496a3201c8ff484feb45b962e7302e56a3" +
+			"92aee4abab3e4bdebf2955b4736012f21a08084056b19bcd7" +
+			"fee56048e004e44984e2f411788efdc837a0d2e5abb7b555" +
+			"039fd243ac01f0fb2ed1dec568280ce678e931868d23eb095" +
+			"fde9d3779191b8c0299d6e07bbb283e6633451e535c45513b" +
+			"2d33c99ea17"),
+	}
+	msg := []byte("For those that envy a MC it can be hazardous to your health\n" +
+		"So be friendly, a matter of life and death, just like a etch-a-sketch\n")
+	h := Hash(msg)
+	assert.Equal(t, "d2d0714f014a9784047eaeccf956520045c45265", h.Text(16))
+
+	r, _ := new(big.Int).SetString("548099063082341131477253921760299949438196259240", 10)
+	s, _ := new(big.Int).SetString("857042759984254168557880549501802188789837994940", 10)
+	sig := &Signature{R: r, S: s}
+	require.True(t, pub.Verify(msg, sig))
+
+	k, err := RecoverKeySmallNonce(pub, h, sig, 1<<16)
+	require.NoError(t, err)
+	assert.Equal(t, "0954edd5e0afe5542a4adf012611a91912a3ec16", Fingerprint(k.X))
+
+	// the recovered key signs messages the public key accepts
+	forged, err := k.Sign([]byte("hi mom"))
+	require.NoError(t, err)
+	assert.True(t, pub.Verify([]byte("hi mom"), forged))
+
+	_, err = RecoverKeySmallNonce(pub, h, sig, 10)
+	require.ErrorIs(t, err, ErrKeyNotFound)
+}
+
+// readSignedMessages parses the msg/s/r/m records of a challenge 44 file
+func readSignedMessages(t *testing.T, file string) ([]string, []SignedMessage) {
+	t.Helper()
+	b, err := os.ReadFile(file)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimRight(string(b), "\n"), "\n")
+	require.Zero(t, len(lines)%4)
+
+	field := func(line, name string) string {
+		require.True(t, strings.HasPrefix(line, name+": "), line)
+		return strings.TrimPrefix(line, name+": ")
+	}
+	var (
+		msgs   []string
+		signed []SignedMessage
+	)
+	for i := 0; i < len(lines); i += 4 {
+		msg := field(lines[i], "msg")
+		s, ok := new(big.Int).SetString(field(lines[i+1], "s"), 10)
+		require.True(t, ok)
+		r, ok := new(big.Int).SetString(field(lines[i+2], "r"), 10)
+		require.True(t, ok)
+		m, ok := new(big.Int).SetString(field(lines[i+3], "m"), 16)
+		require.True(t, ok)
+
+		h := Hash([]byte(msg))
+		require.Equal(t, m, h, msg)
+		msgs = append(msgs, msg)
+		signed = append(signed, SignedMessage{H: h, Sig: &Signature{R: r, S: s}})
+	}
+	return msgs, signed
+}
+
+// set 6 challenge 44
+func TestRecoverKeyRepeatedNonce(t *testing.T) {
+	pub := &PublicKey{
+		Params: CryptopalsParams(),
+		Y: mustHex("2d026f4bf30195ede3a088da85e398ef869611d0f68f0713d" +
+			"51c9c1a3a26c95105d915e2d8cdf26d056b86b8a7b85519b1" +
+			"c23cc3ecdc6062650462e3063bd179c2a6581519f674a61f1" +
+			"d89a1fff27171ebc1b93d4dc57bceb7ae2430f98a6a4d83d8" +
+			"279ee65d71c1203d2c96d65ebbf7cce9d32971c3de5084cce" +
+			"04a2e147821"),
+	}
+	msgs, signed := readSignedMessages(t, "testdata/44.txt")
+	require.Len(t, signed, 11)
+	for i, sm := range signed {
+		require.True(t, pub.Verify([]byte(msgs[i]), sm.Sig), msgs[i])
+	}
+
+	k, err := RecoverKeyRepeatedNonce(pub, signed)
+	require.NoError(t, err)
+	assert.Equal(t, "ca8f6f7c66fa362d40760d135b763eb8527d3d52", Fingerprint(k.X))
+
+	t.Run("no repeated nonce", func(t *testing.T) {
+		// the first 8 signatures all have different r
+		_, err := RecoverKeyRepeatedNonce(pub, signed[:8])
+		require.ErrorIs(t, err, ErrKeyNotFound)
+	})
+}
+
+// set 6 challenge 45
+func TestParameterTampering(t *testing.T) {
+	t.Run("g = 0", func(t *testing.T) {
+		params := CryptopalsParams()
+		k, err := params.GenerateKey()
+		require.NoError(t, err)
+		params.G = big.NewInt(0)
+
+		// signing with g = 0 always gives r = 0
+		_, err = k.Sign([]byte("hi mom"))
+		require.ErrorIs(t, err, ErrSignFailed)
+		sig, err := k.SignWithNonce([]byte("hi mom"), big.NewInt(12345))
+		require.NoError(t, err)
+		assert.Zero(t, sig.R.Sign())
+
+		forged := ZeroGSignature()
+		for _, msg := range []string{"Hello, world", "Goodbye, world"} {
+			assert.True(t, k.VerifyUnchecked([]byte(msg), forged), msg)
+			assert.False(t, k.Verify([]byte(msg), forged), msg)
+		}
+	})
+
+	t.Run("g = p+1", func(t *testing.T) {
+		params := CryptopalsParams()
+		k, err := params.GenerateKey()
+		require.NoError(t, err)
+		params.G = new(big.Int).Add(params.P, big.NewInt(1))
+
+		for i, msg := range []string{"Hello, world", "Goodbye, world"} {
+			sig, err := MagicSignature(&k.PublicKey, big.NewInt(int64(i+2)))
+			require.NoError(t, err)
+			assert.True(t, k.Verify([]byte(msg), sig), msg)
+		}
+	})
+}
+
+func TestFingerprint(t *testing.T) {
+	// sha1 of "1"
+	assert.Equal(t, "356a192b7913b04c54574d18c28d46e6395428ab", Fingerprint(big.NewInt(1)))
+}
@@ -0,0 +1,21 @@
+// Package mdpad is the message padding shared by the Merkle-Damgård hashes
+package mdpad
+
+import "encoding/binary"
+
+// BlockSize is the block size of SHA-1 and MD4
+const BlockSize = 64
+
+// Padding returns the bytes appended to a message of msgLen bytes before
+// the final compression: 0x80, zeros up to 56 mod 64, then the message
+// length in bits written as 8 bytes in the hash's byte order
+func Padding(msgLen uint64, order binary.ByteOrder) []byte {
+	zeros := (BlockSize - 9 - int(msgLen%BlockSize)) % BlockSize
+	if zeros < 0 {
+		zeros += BlockSize
+	}
+	pad := make([]byte, 1+zeros+8)
+	pad[0] = 0x80
+	order.PutUint64(pad[1+zeros:], msgLen<<3)
+	return pad
+}
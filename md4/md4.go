@@ -0,0 +1,146 @@
+// Package md4 is MD4 (RFC 1320) with settable internal state, for
+// length extension attacks
+package md4
+
+import (
+	"encoding/binary"
+	"math/bits"
+
+	"github.com/krehermann/go-cryptopals/internal/mdpad"
+)
+
+const (
+	Size      = 16
+	BlockSize = mdpad.BlockSize
+)
+
+var initState = [4]uint32{0x67452301, 0xefcdab89, 0x98badcfe, 0x10325476}
+
+var (
+	round2Order = [16]int{0, 4, 8, 12, 1, 5, 9, 13, 2, 6, 10, 14, 3, 7, 11, 15}
+	round3Order = [16]int{0, 8, 4, 12, 2, 10, 6, 14, 1, 9, 5, 13, 3, 11, 7, 15}
+	round1Shift = [4]int{3, 7, 11, 19}
+	round2Shift = [4]int{3, 5, 9, 13}
+	round3Shift = [4]int{3, 9, 11, 15}
+)
+
+// Digest implements hash.Hash
+type Digest struct {
+	h [4]uint32
+	x [BlockSize]byte
+	// nx is the number of bytes buffered in x
+	nx  int
+	len uint64
+}
+
+func New() *Digest {
+	d := &Digest{}
+	d.Reset()
+	return d
+}
+
+// NewFromState resumes hashing as if processedLen bytes, a multiple of the block
+// size including padding, had already been compressed into the registers h
+func NewFromState(h [4]uint32, processedLen uint64) *Digest {
+	return &Digest{
+		h:   h,
+		len: processedLen,
+	}
+}
+
+// StateFromSum recovers the registers from a digest
+func StateFromSum(sum [Size]byte) [4]uint32 {
+	var h [4]uint32
+	for i := range h {
+		h[i] = binary.LittleEndian.Uint32(sum[4*i:])
+	}
+	return h
+}
+
+// GluePadding is the padding MD4 appends to a message of msgLen bytes.
+// the same as SHA-1 except the length is little endian
+func GluePadding(msgLen uint64) []byte {
+	return mdpad.Padding(msgLen, binary.LittleEndian)
+}
+
+func Sum(data []byte) [Size]byte {
+	d := New()
+	d.Write(data)
+	var out [Size]byte
+	copy(out[:], d.Sum(nil))
+	return out
+}
+
+func (d *Digest) Reset() {
+	d.h = initState
+	d.nx = 0
+	d.len = 0
+}
+
+func (d *Digest) Size() int { return Size }
+
+func (d *Digest) BlockSize() int { return BlockSize }
+
+func (d *Digest) Write(p []byte) (int, error) {
+	n := len(p)
+	d.len += uint64(n)
+	if d.nx > 0 {
+		c := copy(d.x[d.nx:], p)
+		d.nx += c
+		if d.nx == BlockSize {
+			block(&d.h, d.x[:])
+			d.nx = 0
+		}
+		p = p[c:]
+	}
+	for len(p) >= BlockSize {
+		block(&d.h, p[:BlockSize])
+		p = p[BlockSize:]
+	}
+	if len(p) > 0 {
+		d.nx = copy(d.x[:], p)
+	}
+	return n, nil
+}
+
+// Sum appends the digest to in without changing the state of d
+func (d *Digest) Sum(in []byte) []byte {
+	cp := *d
+	cp.Write(GluePadding(d.len))
+
+	out := make([]byte, Size)
+	for i, v := range cp.h {
+		binary.LittleEndian.PutUint32(out[4*i:], v)
+	}
+	return append(in, out...)
+}
+
+func block(h *[4]uint32, p []byte) {
+	var x [16]uint32
+	for i := range x {
+		x[i] = binary.LittleEndian.Uint32(p[4*i:])
+	}
+
+	a, b, c, d := h[0], h[1], h[2], h[3]
+	// each step updates a, then the registers rotate so the next step updates d
+	for i := 0; i < 16; i++ {
+		f := (b & c) | (^b & d)
+		a = bits.RotateLeft32(a+f+x[i], round1Shift[i%4])
+		a, b, c, d = d, a, b, c
+	}
+	for i := 0; i < 16; i++ {
+		g := (b & c) | (b & d) | (c & d)
+		a = bits.RotateLeft32(a+g+x[round2Order[i]]+0x5a827999, round2Shift[i%4])
+		a, b, c, d = d, a, b, c
+	}
+	for i := 0; i < 16; i++ {
+		hh := b ^ c ^ d
+		a = bits.RotateLeft32(a+hh+x[round3Order[i]]+0x6ed9eba1, round3Shift[i%4])
+		a, b, c, d = d, a, b, c
+	}
+
+	h[0] += a
+	h[1] += b
+	h[2] += c
+	h[3] += d
+}
@@ -0,0 +1,56 @@
+package md4
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// RFC 1320 appendix A.5
+func TestSum(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "", want: "31d6cfe0d16ae931b73c59d7e0c089c0"},
+		{in: "a", want: "bde52cb31de33e46245e05fbdbd6fb24"},
+		{in: "abc", want: "a448017aaf21d8525fc10ae87aa6729d"},
+		{in: "message digest", want: "d9130a8164549fe818874806e1c7014b"},
+		{in: "abcdefghijklmnopqrstuvwxyz", want: "d79e1c308aa5bbcdeea8ed63df412da9"},
+		{in: "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789", want: "043f8582f241db351ce627e153e7f0e4"},
+		{in: "12345678901234567890123456789012345678901234567890123456789012345678901234567890", want: "e33b4ddc9c38f2199c3e7b164fcc0536"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got := Sum([]byte(tt.in))
+			assert.Equal(t, tt.want, hex.EncodeToString(got[:]))
+
+			d := New()
+			for _, r := range []byte(tt.in) {
+				d.Write([]byte{r})
+			}
+			assert.Equal(t, tt.want, hex.EncodeToString(d.Sum(nil)))
+		})
+	}
+}
+
+func TestNewFromState(t *testing.T) {
+	msg := []byte("the original message")
+	ext := []byte("more")
+
+	sum := Sum(msg)
+	glue := GluePadding(uint64(len(msg)))
+	processed := uint64(len(msg) + len(glue))
+	require.Zero(t, processed%BlockSize)
+
+	d := NewFromState(StateFromSum(sum), processed)
+	d.Write(ext)
+
+	full := append(append(append([]byte{}, msg...), glue...), ext...)
+	want := Sum(full)
+	assert.Equal(t, want[:], d.Sum(nil))
+	assert.Len(t, GluePadding(uint64(len(strings.Repeat("x", 55)))), 9)
+}
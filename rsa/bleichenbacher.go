@@ -0,0 +1,154 @@
+package rsa
+
+import (
+	"errors"
+	"math/big"
+)
+
+var ErrNotConforming = errors.New("ciphertext is not PKCS#1 conforming")
+
+type interval struct {
+	a *big.Int
+	b *big.Int
+}
+
+// Bleichenbacher recovers the padded plaintext of c, a PKCS#1 v1.5
+// conforming ciphertext, from an oracle that reports whether a ciphertext
+// decrypts to something starting with 00 02. it returns the plaintext and
+// the number of oracle queries used
+//
+// Bleichenbacher, "Chosen Ciphertext Attacks Against Protocols Based on the
+// RSA Encryption Standard PKCS #1", CRYPTO '98
+func Bleichenbacher(pub *PublicKey, c *big.Int, conforming func(*big.Int) bool) (*big.Int, int, error) {
+	n, e := pub.N, pub.E
+	queries := 0
+	// try reports whether c * s^e decrypts to a conforming plaintext
+	try := func(s *big.Int) bool {
+		queries++
+		cs := new(big.Int).Exp(s, e, n)
+		cs.Mul(cs, c)
+		cs.Mod(cs, n)
+		return conforming(cs)
+	}
+
+	// step 1: c is already conforming, so s0 = 1
+	if !conforming(c) {
+		return nil, 1, ErrNotConforming
+	}
+	queries++
+
+	B := new(big.Int).Lsh(one, uint(8*(pub.byteLen()-2)))
+	B2 := new(big.Int).Mul(B, big.NewInt(2))
+	B3 := new(big.Int).Mul(B, big.NewInt(3))
+	B3Minus1 := new(big.Int).Sub(B3, one)
+
+	M := []interval{{a: new(big.Int).Set(B2), b: new(big.Int).Set(B3Minus1)}}
+	var s *big.Int
+
+	for i := 1; ; i++ {
+		switch {
+		case i == 1:
+			// step 2a: the smallest s >= n/3B that conforms
+			s = ceilDiv(n, B3)
+			for !try(s) {
+				s.Add(s, one)
+			}
+		case len(M) > 1:
+			// step 2b: keep searching upwards
+			s = new(big.Int).Add(s, one)
+			for !try(s) {
+				s.Add(s, one)
+			}
+		default:
+			// step 2c: one interval left, search with r, s pairs that
+			// roughly halve it each time
+			a, b := M[0].a, M[0].b
+			r := new(big.Int).Mul(b, s)
+			r.Sub(r, B2)
+			r.Mul(r, big.NewInt(2))
+			r = ceilDiv(r, n)
+			found := false
+			for !found {
+				rn := new(big.Int).Mul(r, n)
+				lo := ceilDiv(new(big.Int).Add(B2, rn), b)
+				hi := ceilDiv(new(big.Int).Add(B3, rn), a)
+				for s = lo; s.Cmp(hi) < 0; s.Add(s, one) {
+					if try(s) {
+						found = true
+						break
+					}
+				}
+				r.Add(r, one)
+			}
+		}
+
+		// step 3: narrow every interval with the new s
+		M = narrow(M, s, n, B2, B3Minus1)
+		if len(M) == 0 {
+			return nil, queries, errors.New("bleichenbacher: no intervals left")
+		}
+
+		// step 4: done when one value remains
+		if len(M) == 1 && M[0].a.Cmp(M[0].b) == 0 {
+			return M[0].a, queries, nil
+		}
+	}
+}
+
+func narrow(M []interval, s, n, B2, B3Minus1 *big.Int) []interval {
+	var out []interval
+	for _, iv := range M {
+		// r from (a*s - 3B + 1)/n to (b*s - 2B)/n
+		rLo := new(big.Int).Mul(iv.a, s)
+		rLo.Sub(rLo, B3Minus1)
+		rLo = ceilDiv(rLo, n)
+		rHi := new(big.Int).Mul(iv.b, s)
+		rHi.Sub(rHi, B2)
+		rHi.Div(rHi, n)
+
+		for r := rLo; r.Cmp(rHi) <= 0; r = new(big.Int).Add(r, one) {
+			rn := new(big.Int).Mul(r, n)
+			a := ceilDiv(new(big.Int).Add(B2, rn), s)
+			if a.Cmp(iv.a) < 0 {
+				a = iv.a
+			}
+			b := new(big.Int).Add(B3Minus1, rn)
+			b.Div(b, s)
+			if b.Cmp(iv.b) > 0 {
+				b = iv.b
+			}
+			if a.Cmp(b) > 0 {
+				continue
+			}
+			out = union(out, interval{a: a, b: b})
+		}
+	}
+	return out
+}
+
+// union adds iv to M, merging any overlapping intervals
+func union(M []interval, iv interval) []interval {
+	out := make([]interval, 0, len(M)+1)
+	for _, m := range M {
+		if m.b.Cmp(iv.a) < 0 || m.a.Cmp(iv.b) > 0 {
+			out = append(out, m)
+			continue
+		}
+		if m.a.Cmp(iv.a) < 0 {
+			iv.a = m.a
+		}
+		if m.b.Cmp(iv.b) > 0 {
+			iv.b = m.b
+		}
+	}
+	return append(out, iv)
+}
+
+// ceilDiv is ceil(x / y) for y > 0
+func ceilDiv(x, y *big.Int) *big.Int {
+	q, m := new(big.Int).DivMod(x, y, new(big.Int))
+	if m.Sign() != 0 {
+		q.Add(q, one)
+	}
+	return q
+}
@@ -0,0 +1,63 @@
+package rsa
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPKCS1v15(t *testing.T) {
+	k, err := GenerateKey(512, 3)
+	require.NoError(t, err)
+
+	msg := []byte("kick it, CC")
+	c, err := k.EncryptPKCS1v15(msg)
+	require.NoError(t, err)
+	assert.True(t, k.PKCS1Conforming(c))
+
+	got, err := k.DecryptPKCS1v15(c)
+	require.NoError(t, err)
+	assert.Equal(t, msg, got)
+
+	// unpadded messages are not conforming
+	c, err = k.Encrypt(new(big.Int).SetBytes(msg))
+	require.NoError(t, err)
+	assert.False(t, k.PKCS1Conforming(c))
+	_, err = k.DecryptPKCS1v15(c)
+	require.ErrorIs(t, err, ErrInvalidPKCS1)
+
+	_, err = PadPKCS1v15(make([]byte, 54), 64)
+	require.ErrorIs(t, err, ErrMessageTooLarge)
+}
+
+// set 6 challenges 47 and 48
+func TestBleichenbacher(t *testing.T) {
+	for _, bits := range []int{256, 768} {
+		t.Run(fmt.Sprint(bits), func(t *testing.T) {
+			k, err := GenerateKey(bits, 3)
+			require.NoError(t, err)
+
+			msg := []byte("kick it, CC")
+			c, err := k.EncryptPKCS1v15(msg)
+			require.NoError(t, err)
+
+			m, queries, err := Bleichenbacher(&k.PublicKey, c, k.PKCS1Conforming)
+			require.NoError(t, err)
+			t.Logf("%d oracle queries", queries)
+
+			got, err := UnpadPKCS1v15(m.FillBytes(make([]byte, k.byteLen())))
+			require.NoError(t, err)
+			assert.Equal(t, msg, got)
+		})
+	}
+
+	t.Run("not conforming", func(t *testing.T) {
+		k, err := GenerateKey(256, 3)
+		require.NoError(t, err)
+		_, _, err = Bleichenbacher(&k.PublicKey, big.NewInt(2), k.PKCS1Conforming)
+		require.ErrorIs(t, err, ErrNotConforming)
+	})
+}
@@ -0,0 +1,118 @@
+package rsa
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"math/big"
+	"sync"
+)
+
+var ErrAlreadyDecrypted = errors.New("ciphertext already decrypted")
+
+// DecryptionOracle decrypts anything, but only once. it remembers the hash
+// of every ciphertext it has seen
+type DecryptionOracle struct {
+	key *PrivateKey
+
+	mu   sync.Mutex
+	seen map[[sha256.Size]byte]struct{}
+}
+
+func NewDecryptionOracle(key *PrivateKey) *DecryptionOracle {
+	return &DecryptionOracle{
+		key:  key,
+		seen: make(map[[sha256.Size]byte]struct{}),
+	}
+}
+
+func (o *DecryptionOracle) PublicKey() *PublicKey {
+	return &o.key.PublicKey
+}
+
+func (o *DecryptionOracle) Decrypt(c *big.Int) (*big.Int, error) {
+	h := sha256.Sum256(c.Bytes())
+	o.mu.Lock()
+	if _, ok := o.seen[h]; ok {
+		o.mu.Unlock()
+		return nil, ErrAlreadyDecrypted
+	}
+	o.seen[h] = struct{}{}
+	o.mu.Unlock()
+
+	return o.key.Decrypt(c)
+}
+
+// UnpaddedRecovery decrypts c with an oracle that refuses c itself: it asks
+// for the blinded S^e * c instead, which decrypts to S * m
+func UnpaddedRecovery(pub *PublicKey, c *big.Int, decrypt func(*big.Int) (*big.Int, error)) (*big.Int, error) {
+	var s, sInv *big.Int
+	for sInv == nil {
+		var err error
+		s, err = rand.Int(rand.Reader, pub.N)
+		if err != nil {
+			return nil, err
+		}
+		if s.Cmp(one) <= 0 {
+			continue
+		}
+		// s almost always shares no factor with n, and if it does, try another
+		sInv, _ = InvMod(s, pub.N)
+	}
+
+	blinded := new(big.Int).Exp(s, pub.E, pub.N)
+	blinded.Mul(blinded, c)
+	blinded.Mod(blinded, pub.N)
+
+	p, err := decrypt(blinded)
+	if err != nil {
+		return nil, err
+	}
+	p.Mul(p, sInv)
+	return p.Mod(p, pub.N), nil
+}
+
+// IsEven is a parity oracle: it reports whether c decrypts to an even number
+func (k *PrivateKey) IsEven(c *big.Int) bool {
+	m, err := k.Decrypt(c)
+	if err != nil {
+		return false
+	}
+	return m.Bit(0) == 0
+}
+
+// ParityAttack recovers the plaintext of c from a parity oracle. doubling
+// the plaintext (multiplying c by 2^e) wraps the modulus, and so becomes
+// odd, exactly when the plaintext is in the upper half of its current
+// range. each query halves the range. progress, if not nil, is called
+// with the current upper bound after every query
+func ParityAttack(pub *PublicKey, c *big.Int, isEven func(*big.Int) bool, progress func(upper []byte)) *big.Int {
+	double := new(big.Int).Exp(big.NewInt(2), pub.E, pub.N)
+
+	// the plaintext is in [lo * n / 2^k, hi * n / 2^k), and hi - lo is always 1
+	lo, hi := big.NewInt(0), big.NewInt(1)
+	cur := new(big.Int).Set(c)
+	k := uint(0)
+	bound := func() *big.Int {
+		b := new(big.Int).Mul(hi, pub.N)
+		return b.Rsh(b, k)
+	}
+
+	for i := 0; i < pub.N.BitLen(); i++ {
+		cur.Mul(cur, double)
+		cur.Mod(cur, pub.N)
+
+		lo.Lsh(lo, 1)
+		hi.Lsh(hi, 1)
+		k++
+		if isEven(cur) {
+			hi.Sub(hi, one)
+		} else {
+			lo.Add(lo, one)
+		}
+		if progress != nil {
+			progress(bound().Bytes())
+		}
+	}
+	return bound()
+}
@@ -0,0 +1,53 @@
+package rsa
+
+import (
+	"encoding/base64"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// set 6 challenge 41
+func TestUnpaddedRecovery(t *testing.T) {
+	k, err := GenerateKey(1024, 65537)
+	require.NoError(t, err)
+	oracle := NewDecryptionOracle(k)
+
+	m := new(big.Int).SetBytes([]byte(`{"time": 1356304276, "social": "555-55-5555"}`))
+	c, err := oracle.PublicKey().Encrypt(m)
+	require.NoError(t, err)
+
+	// the victim decrypts it first
+	got, err := oracle.Decrypt(c)
+	require.NoError(t, err)
+	assert.Equal(t, m, got)
+	_, err = oracle.Decrypt(c)
+	require.ErrorIs(t, err, ErrAlreadyDecrypted)
+
+	recovered, err := UnpaddedRecovery(oracle.PublicKey(), c, oracle.Decrypt)
+	require.NoError(t, err)
+	assert.Equal(t, m, recovered)
+}
+
+// set 6 challenge 46
+func TestParityAttack(t *testing.T) {
+	k, err := GenerateKey(1024, 65537)
+	require.NoError(t, err)
+
+	msg, err := base64.StdEncoding.DecodeString("VGhhdCdzIHdoeSBJIGZvdW5kIHlvdSBkb24ndCBwbGF5IGFyb3VuZCB3aXRoIHRoZSBGdW5reSBDb2xkIE1lZGluYQ==")
+	require.NoError(t, err)
+	c, err := k.Encrypt(new(big.Int).SetBytes(msg))
+	require.NoError(t, err)
+
+	queries := 0
+	got := ParityAttack(&k.PublicKey, c, k.IsEven, func(upper []byte) {
+		queries++
+		if queries%128 == 0 {
+			t.Logf("%q", upper)
+		}
+	})
+	assert.Equal(t, string(msg), string(got.Bytes()))
+	assert.Equal(t, k.N.BitLen(), queries)
+}
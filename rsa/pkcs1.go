@@ -0,0 +1,86 @@
+package rsa
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+var ErrInvalidPKCS1 = errors.New("invalid PKCS#1 v1.5 padding")
+
+// minPadLen is the minimum number of random padding bytes
+const minPadLen = 8
+
+// byteLen is the length of the modulus in bytes
+func (k *PublicKey) byteLen() int {
+	return (k.N.BitLen() + 7) / 8
+}
+
+// PadPKCS1v15 is encryption block type 2: 00 02 | nonzero random bytes | 00 | msg,
+// k bytes in total
+func PadPKCS1v15(msg []byte, k int) ([]byte, error) {
+	padLen := k - 3 - len(msg)
+	if padLen < minPadLen {
+		return nil, fmt.Errorf("%w: %d byte message does not fit in %d bytes", ErrMessageTooLarge, len(msg), k)
+	}
+	out := make([]byte, k)
+	out[1] = 2
+	ps := out[2 : 2+padLen]
+	if _, err := rand.Read(ps); err != nil {
+		return nil, err
+	}
+	for i := range ps {
+		for ps[i] == 0 {
+			if _, err := rand.Read(ps[i : i+1]); err != nil {
+				return nil, err
+			}
+		}
+	}
+	copy(out[3+padLen:], msg)
+	return out, nil
+}
+
+// UnpadPKCS1v15 reverses PadPKCS1v15
+func UnpadPKCS1v15(em []byte) ([]byte, error) {
+	if len(em) < 3+minPadLen || em[0] != 0 || em[1] != 2 {
+		return nil, ErrInvalidPKCS1
+	}
+	for i := 2; i < len(em); i++ {
+		if em[i] == 0 {
+			if i-2 < minPadLen {
+				return nil, ErrInvalidPKCS1
+			}
+			return em[i+1:], nil
+		}
+	}
+	return nil, ErrInvalidPKCS1
+}
+
+// EncryptPKCS1v15 pads msg to the size of the modulus and encrypts it
+func (k *PublicKey) EncryptPKCS1v15(msg []byte) (*big.Int, error) {
+	em, err := PadPKCS1v15(msg, k.byteLen())
+	if err != nil {
+		return nil, err
+	}
+	return k.Encrypt(new(big.Int).SetBytes(em))
+}
+
+func (k *PrivateKey) DecryptPKCS1v15(c *big.Int) ([]byte, error) {
+	m, err := k.Decrypt(c)
+	if err != nil {
+		return nil, err
+	}
+	return UnpadPKCS1v15(m.FillBytes(make([]byte, k.byteLen())))
+}
+
+// PKCS1Conforming is a padding oracle that only checks the plaintext
+// starts with 00 02
+func (k *PrivateKey) PKCS1Conforming(c *big.Int) bool {
+	m, err := k.Decrypt(c)
+	if err != nil {
+		return false
+	}
+	em := m.FillBytes(make([]byte, k.byteLen()))
+	return em[0] == 0 && em[1] == 2
+}
@@ -0,0 +1,207 @@
+// Package rsa is textbook RSA over math/big: no padding, so do not use it
+// for anything but breaking it
+package rsa
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+var (
+	ErrMessageTooLarge = errors.New("message too large for modulus")
+	ErrNoInverse       = errors.New("no modular inverse")
+	ErrKeyGeneration   = errors.New("no primes found that suit the public exponent")
+)
+
+// maxKeyAttempts bounds the primes GenerateKey draws looking for a pair
+// where e is invertible mod (p-1)(q-1)
+const maxKeyAttempts = 100
+
+var one = big.NewInt(1)
+
+type PublicKey struct {
+	N *big.Int
+	E *big.Int
+}
+
+// PrivateKey keeps the CRT values so Decrypt works mod p and q separately
+type PrivateKey struct {
+	PublicKey
+	D *big.Int
+	P *big.Int
+	Q *big.Int
+	// Dp = d mod p-1, Dq = d mod q-1, Qinv = q^-1 mod p
+	Dp   *big.Int
+	Dq   *big.Int
+	Qinv *big.Int
+}
+
+// GenerateKey makes a key with a modulus of the given size and public exponent e
+func GenerateKey(bits int, e int64) (*PrivateKey, error) {
+	if bits < 16 {
+		return nil, fmt.Errorf("key size %d too small", bits)
+	}
+	// (p-1)(q-1) is even, so an even e never has an inverse
+	if e < 3 || e%2 == 0 {
+		return nil, fmt.Errorf("public exponent %d must be odd and at least 3", e)
+	}
+	E := big.NewInt(e)
+	for attempt := 0; attempt < maxKeyAttempts; attempt++ {
+		p, err := rand.Prime(rand.Reader, bits-bits/2)
+		if err != nil {
+			return nil, err
+		}
+		q, err := rand.Prime(rand.Reader, bits/2)
+		if err != nil {
+			return nil, err
+		}
+		if p.Cmp(q) == 0 {
+			continue
+		}
+		n := new(big.Int).Mul(p, q)
+		if n.BitLen() != bits {
+			continue
+		}
+
+		pMinus1 := new(big.Int).Sub(p, one)
+		qMinus1 := new(big.Int).Sub(q, one)
+		et := new(big.Int).Mul(pMinus1, qMinus1)
+		d, err := InvMod(E, et)
+		if err != nil {
+			// e shares a factor with (p-1)(q-1), try again
+			continue
+		}
+		qinv, err := InvMod(q, p)
+		if err != nil {
+			continue
+		}
+		return &PrivateKey{
+			PublicKey: PublicKey{N: n, E: E},
+			D:         d,
+			P:         p,
+			Q:         q,
+			Dp:        new(big.Int).Mod(d, pMinus1),
+			Dq:        new(big.Int).Mod(d, qMinus1),
+			Qinv:      qinv,
+		}, nil
+	}
+	return nil, fmt.Errorf("%w: e = %d after %d attempts", ErrKeyGeneration, e, maxKeyAttempts)
+}
+
+// Encrypt is m^e mod n
+func (k *PublicKey) Encrypt(m *big.Int) (*big.Int, error) {
+	if m.Sign() < 0 || m.Cmp(k.N) >= 0 {
+		return nil, ErrMessageTooLarge
+	}
+	return new(big.Int).Exp(m, k.E, k.N), nil
+}
+
+// Decrypt is c^d mod n, computed with the CRT
+func (k *PrivateKey) Decrypt(c *big.Int) (*big.Int, error) {
+	if c.Sign() < 0 || c.Cmp(k.N) >= 0 {
+		return nil, ErrMessageTooLarge
+	}
+	m1 := new(big.Int).Exp(c, k.Dp, k.P)
+	m2 := new(big.Int).Exp(c, k.Dq, k.Q)
+	// h = qinv * (m1 - m2) mod p; m = m2 + h * q
+	h := new(big.Int).Sub(m1, m2)
+	h.Mul(h, k.Qinv)
+	h.Mod(h, k.P)
+	h.Mul(h, k.Q)
+	return h.Add(h, m2), nil
+}
+
+// EncryptBytes encrypts msg read as a big endian integer
+func (k *PublicKey) EncryptBytes(msg []byte) ([]byte, error) {
+	c, err := k.Encrypt(new(big.Int).SetBytes(msg))
+	if err != nil {
+		return nil, err
+	}
+	return c.Bytes(), nil
+}
+
+// DecryptBytes reverses EncryptBytes. leading zero bytes of the message are lost
+func (k *PrivateKey) DecryptBytes(ct []byte) ([]byte, error) {
+	m, err := k.Decrypt(new(big.Int).SetBytes(ct))
+	if err != nil {
+		return nil, err
+	}
+	return m.Bytes(), nil
+}
+
+// InvMod finds x with a*x = 1 mod m using the extended Euclidean algorithm
+func InvMod(a, m *big.Int) (*big.Int, error) {
+	// invariant: oldR = oldS*a mod m, r = s*a mod m
+	oldR, r := new(big.Int).Mod(a, m), new(big.Int).Set(m)
+	oldS, s := big.NewInt(1), big.NewInt(0)
+	for r.Sign() != 0 {
+		q := new(big.Int).Div(oldR, r)
+		oldR, r = r, new(big.Int).Sub(oldR, new(big.Int).Mul(q, r))
+		oldS, s = s, new(big.Int).Sub(oldS, new(big.Int).Mul(q, s))
+	}
+	if oldR.Cmp(one) != 0 {
+		return nil, fmt.Errorf("%w: gcd(%s, %s) = %s", ErrNoInverse, a, m, oldR)
+	}
+	return oldS.Mod(oldS, m), nil
+}
+
+// CubeRoot is the floor of the cube root of n
+func CubeRoot(n *big.Int) *big.Int {
+	return Root(n, 3)
+}
+
+// Root is the floor of the kth root of n >= 0, by Newton's method
+func Root(n *big.Int, k int) *big.Int {
+	if n.Sign() == 0 {
+		return new(big.Int)
+	}
+	K := big.NewInt(int64(k))
+	kMinus1 := big.NewInt(int64(k - 1))
+	// start above the root: 2^ceil(bitlen/k)
+	x := new(big.Int).Lsh(one, uint(n.BitLen()/k+1))
+	for {
+		// y = ((k-1)x + n / x^(k-1)) / k
+		y := new(big.Int).Exp(x, kMinus1, nil)
+		y.Div(n, y)
+		y.Add(y, new(big.Int).Mul(kMinus1, x))
+		y.Div(y, K)
+		if y.Cmp(x) >= 0 {
+			return x
+		}
+		x = y
+	}
+}
+
+// BroadcastAttack recovers a message encrypted under e = 3 to three
+// different public keys: the CRT gives m^3 mod n1*n2*n3, and since m^3 is
+// smaller than that, the integer cube root is m
+func BroadcastAttack(cts []*big.Int, keys []*PublicKey) (*big.Int, error) {
+	if len(cts) != 3 || len(keys) != 3 {
+		return nil, fmt.Errorf("need 3 ciphertexts and keys, got %d and %d", len(cts), len(keys))
+	}
+	for _, k := range keys {
+		if k.E.Cmp(big.NewInt(3)) != 0 {
+			return nil, fmt.Errorf("broadcast attack needs e = 3, got %s", k.E)
+		}
+	}
+
+	n012 := new(big.Int).Mul(keys[0].N, keys[1].N)
+	n012.Mul(n012, keys[2].N)
+
+	result := new(big.Int)
+	for i := range cts {
+		// ms = product of the other moduli
+		ms := new(big.Int).Div(n012, keys[i].N)
+		inv, err := InvMod(ms, keys[i].N)
+		if err != nil {
+			return nil, err
+		}
+		term := new(big.Int).Mul(cts[i], ms)
+		term.Mul(term, inv)
+		result.Add(result, term)
+	}
+	result.Mod(result, n012)
+	return CubeRoot(result), nil
+}
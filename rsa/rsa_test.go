@@ -0,0 +1,112 @@
+package rsa
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInvMod(t *testing.T) {
+	got, err := InvMod(big.NewInt(17), big.NewInt(3120))
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(2753), got)
+
+	got, err = InvMod(big.NewInt(3), big.NewInt(7))
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(5), got)
+
+	_, err = InvMod(big.NewInt(6), big.NewInt(9))
+	require.ErrorIs(t, err, ErrNoInverse)
+}
+
+func TestRoot(t *testing.T) {
+	for _, n := range []int64{0, 1, 7, 8, 26, 27, 28, 1000, 999999} {
+		r := CubeRoot(big.NewInt(n)).Int64()
+		assert.LessOrEqual(t, r*r*r, n, "n=%d", n)
+		assert.Greater(t, (r+1)*(r+1)*(r+1), n, "n=%d", n)
+	}
+
+	big1 := new(big.Int).Lsh(big.NewInt(1), 3000)
+	big1.Add(big1, big.NewInt(12345))
+	cube := new(big.Int).Exp(big1, big.NewInt(3), nil)
+	assert.Equal(t, big1, CubeRoot(cube))
+	assert.Equal(t, big1, CubeRoot(cube.Add(cube, big.NewInt(1))))
+
+	assert.Equal(t, big.NewInt(10), Root(big.NewInt(100000), 5))
+}
+
+// set 5 challenge 39
+func TestRSA(t *testing.T) {
+	for _, bits := range []int{1024, 2048} {
+		t.Run(fmt.Sprint(bits), func(t *testing.T) {
+			k, err := GenerateKey(bits, 3)
+			require.NoError(t, err)
+			assert.Equal(t, bits, k.N.BitLen())
+
+			m := big.NewInt(42)
+			c, err := k.Encrypt(m)
+			require.NoError(t, err)
+			got, err := k.Decrypt(c)
+			require.NoError(t, err)
+			assert.Equal(t, m, got)
+
+			// the CRT agrees with the slow way
+			assert.Equal(t, new(big.Int).Exp(c, k.D, k.N), got)
+
+			msg := []byte("attack at dawn")
+			ct, err := k.EncryptBytes(msg)
+			require.NoError(t, err)
+			pt, err := k.DecryptBytes(ct)
+			require.NoError(t, err)
+			assert.Equal(t, msg, pt)
+
+			_, err = k.Encrypt(k.N)
+			require.ErrorIs(t, err, ErrMessageTooLarge)
+		})
+	}
+}
+
+func TestGenerateKey_BadExponent(t *testing.T) {
+	for _, e := range []int64{-3, 0, 1, 2, 4, 65536} {
+		_, err := GenerateKey(256, e)
+		require.Error(t, err, "e = %d", e)
+	}
+
+	// 16 bit keys are made of primes between 192 and 255. this e shares a
+	// factor with p-1 for every one of them, so it is never invertible
+	_, err := GenerateKey(16, 3*5*7*11*17*19*29*37*113)
+	require.ErrorIs(t, err, ErrKeyGeneration)
+}
+
+// set 5 challenge 40
+func TestBroadcastAttack(t *testing.T) {
+	for _, bits := range []int{1024, 2048} {
+		t.Run(fmt.Sprint(bits), func(t *testing.T) {
+			// one byte short of the modulus, so m^3 wraps every modulus and
+			// no single ciphertext is an exact cube
+			msg := []byte(strings.Repeat("the same message sent three times ", bits/8/34+1))
+			m := new(big.Int).SetBytes(msg[:bits/8-1])
+			cube := new(big.Int).Exp(m, big.NewInt(3), nil)
+
+			keys := make([]*PublicKey, 3)
+			cts := make([]*big.Int, 3)
+			for i := range keys {
+				k, err := GenerateKey(bits, 3)
+				require.NoError(t, err)
+				keys[i] = &k.PublicKey
+				require.Equal(t, 1, cube.Cmp(keys[i].N))
+				cts[i], err = keys[i].Encrypt(m)
+				require.NoError(t, err)
+				assert.NotEqual(t, m, CubeRoot(cts[i]))
+			}
+
+			got, err := BroadcastAttack(cts, keys)
+			require.NoError(t, err)
+			assert.Equal(t, m, got)
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package rsa
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/krehermann/go-cryptopals/sha1"
+)
+
+var ErrVerification = errors.New("rsa: verification error")
+
+// Hash selects the digest inside a PKCS#1 v1.5 signature
+type Hash int
+
+const (
+	SHA1 Hash = iota
+	SHA256
+)
+
+func (h Hash) String() string {
+	switch h {
+	case SHA1:
+		return "SHA-1"
+	case SHA256:
+		return "SHA-256"
+	}
+	return fmt.Sprintf("Hash(%d)", int(h))
+}
+
+// digestInfoPrefix is the DER encoded DigestInfo that precedes the digest
+var digestInfoPrefix = map[Hash][]byte{
+	SHA1:   {0x30, 0x21, 0x30, 0x09, 0x06, 0x05, 0x2b, 0x0e, 0x03, 0x02, 0x1a, 0x05, 0x00, 0x04, 0x14},
+	SHA256: {0x30, 0x31, 0x30, 0x0d, 0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x01, 0x05, 0x00, 0x04, 0x20},
+}
+
+// digestInfo is the DER DigestInfo of msg
+func (h Hash) digestInfo(msg []byte) ([]byte, error) {
+	prefix, ok := digestInfoPrefix[h]
+	if !ok {
+		return nil, fmt.Errorf("unknown hash %d", h)
+	}
+	var sum []byte
+	switch h {
+	case SHA1:
+		s := sha1.Sum(msg)
+		sum = s[:]
+	case SHA256:
+		s := sha256.Sum256(msg)
+		sum = s[:]
+	}
+	return append(append([]byte{}, prefix...), sum...), nil
+}
+
+// signatureBlock is 00 01 FF..FF 00 DigestInfo, k bytes long
+func signatureBlock(h Hash, msg []byte, k int) ([]byte, error) {
+	di, err := h.digestInfo(msg)
+	if err != nil {
+		return nil, err
+	}
+	padLen := k - 3 - len(di)
+	if padLen < minPadLen {
+		return nil, fmt.Errorf("%w: digest does not fit in %d bytes", ErrMessageTooLarge, k)
+	}
+	em := make([]byte, k)
+	em[1] = 1
+	for i := 2; i < 2+padLen; i++ {
+		em[i] = 0xff
+	}
+	copy(em[3+padLen:], di)
+	return em, nil
+}
+
+// SignPKCS1v15 signs the digest of msg with PKCS#1 v1.5 padding
+func (k *PrivateKey) SignPKCS1v15(h Hash, msg []byte) ([]byte, error) {
+	em, err := signatureBlock(h, msg, k.byteLen())
+	if err != nil {
+		return nil, err
+	}
+	s, err := k.Decrypt(new(big.Int).SetBytes(em))
+	if err != nil {
+		return nil, err
+	}
+	return s.FillBytes(make([]byte, k.byteLen())), nil
+}
+
+// open is sig^e mod n as a k byte block
+func (k *PublicKey) open(sig []byte) ([]byte, error) {
+	s := new(big.Int).SetBytes(sig)
+	if len(sig) != k.byteLen() || s.Cmp(k.N) >= 0 {
+		return nil, ErrVerification
+	}
+	m := new(big.Int).Exp(s, k.E, k.N)
+	return m.FillBytes(make([]byte, k.byteLen())), nil
+}
+
+// VerifyPKCS1v15 rebuilds the whole signature block and compares it
+func (k *PublicKey) VerifyPKCS1v15(h Hash, msg, sig []byte) error {
+	em, err := k.open(sig)
+	if err != nil {
+		return err
+	}
+	want, err := signatureBlock(h, msg, k.byteLen())
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(em, want) {
+		return ErrVerification
+	}
+	return nil
+}
+
+// SloppyVerifyPKCS1v15 parses the block left to right, like many broken
+// implementations did, and never checks that the digest ends the block
+func (k *PublicKey) SloppyVerifyPKCS1v15(h Hash, msg, sig []byte) error {
+	em, err := k.open(sig)
+	if err != nil {
+		return err
+	}
+	if em[0] != 0 || em[1] != 1 {
+		return ErrVerification
+	}
+	i := 2
+	for i < len(em) && em[i] == 0xff {
+		i++
+	}
+	if i == 2 || i >= len(em) || em[i] != 0 {
+		return ErrVerification
+	}
+	di, err := h.digestInfo(msg)
+	if err != nil {
+		return err
+	}
+	if !bytes.HasPrefix(em[i+1:], di) {
+		return ErrVerification
+	}
+	return nil
+}
+
+// ForgeSignatureE3 forges a signature for an e = 3 key that passes the
+// sloppy verifier: 00 01 FF 00 DigestInfo followed by garbage. the cube
+// root of the largest such block, cubed, keeps the prefix intact as long as
+// there is enough room for the garbage
+func ForgeSignatureE3(pub *PublicKey, h Hash, msg []byte) ([]byte, error) {
+	if pub.E.Cmp(big.NewInt(3)) != 0 {
+		return nil, fmt.Errorf("forgery needs e = 3, got %s", pub.E)
+	}
+	di, err := h.digestInfo(msg)
+	if err != nil {
+		return nil, err
+	}
+	prefix := append([]byte{0x00, 0x01, 0xff, 0x00}, di...)
+	k := pub.byteLen()
+	if len(prefix) > k {
+		return nil, ErrMessageTooLarge
+	}
+
+	// largest block with the prefix; its floored cube root cubes to
+	// something no larger, that still starts with the prefix if we're lucky
+	block := make([]byte, k)
+	copy(block, prefix)
+	for i := len(prefix); i < k; i++ {
+		block[i] = 0xff
+	}
+	s := CubeRoot(new(big.Int).SetBytes(block))
+
+	forged := new(big.Int).Exp(s, big.NewInt(3), nil).FillBytes(make([]byte, k))
+	if !bytes.HasPrefix(forged, prefix) {
+		return nil, fmt.Errorf("%d byte modulus leaves too little room for garbage", k)
+	}
+	return s.FillBytes(make([]byte, k)), nil
+}
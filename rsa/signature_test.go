@@ -0,0 +1,64 @@
+package rsa
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignPKCS1v15(t *testing.T) {
+	k, err := GenerateKey(1024, 65537)
+	require.NoError(t, err)
+	msg := []byte("hi mom")
+
+	for _, h := range []Hash{SHA1, SHA256} {
+		t.Run(fmt.Sprint(h), func(t *testing.T) {
+			sig, err := k.SignPKCS1v15(h, msg)
+			require.NoError(t, err)
+			assert.Len(t, sig, 128)
+
+			require.NoError(t, k.VerifyPKCS1v15(h, msg, sig))
+			require.NoError(t, k.SloppyVerifyPKCS1v15(h, msg, sig))
+
+			require.ErrorIs(t, k.VerifyPKCS1v15(h, []byte("hi dad"), sig), ErrVerification)
+			require.ErrorIs(t, k.SloppyVerifyPKCS1v15(h, []byte("hi dad"), sig), ErrVerification)
+
+			sig[len(sig)-1] ^= 1
+			require.ErrorIs(t, k.VerifyPKCS1v15(h, msg, sig), ErrVerification)
+		})
+	}
+}
+
+// set 6 challenge 42
+func TestForgeSignatureE3(t *testing.T) {
+	msg := []byte("hi mom")
+	tests := []struct {
+		name string
+		bits int
+		hash Hash
+	}{
+		{name: "sha1 1024", bits: 1024, hash: SHA1},
+		{name: "sha256 2048", bits: 2048, hash: SHA256},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k, err := GenerateKey(tt.bits, 3)
+			require.NoError(t, err)
+
+			forged, err := ForgeSignatureE3(&k.PublicKey, tt.hash, msg)
+			require.NoError(t, err)
+
+			require.NoError(t, k.SloppyVerifyPKCS1v15(tt.hash, msg, forged))
+			require.ErrorIs(t, k.VerifyPKCS1v15(tt.hash, msg, forged), ErrVerification)
+		})
+	}
+
+	t.Run("no room", func(t *testing.T) {
+		k, err := GenerateKey(512, 3)
+		require.NoError(t, err)
+		_, err = ForgeSignatureE3(&k.PublicKey, SHA256, msg)
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,140 @@
+// Package sha1 is a SHA-1 whose internal state can be set, which
+// crypto/sha1 does not allow. that is all a length extension attack needs
+package sha1
+
+import (
+	"encoding/binary"
+	"math/bits"
+
+	"github.com/krehermann/go-cryptopals/internal/mdpad"
+)
+
+const (
+	Size      = 20
+	BlockSize = mdpad.BlockSize
+)
+
+var initState = [5]uint32{0x67452301, 0xefcdab89, 0x98badcfe, 0x10325476, 0xc3d2e1f0}
+
+// Digest implements hash.Hash
+type Digest struct {
+	h [5]uint32
+	x [BlockSize]byte
+	// nx is the number of bytes buffered in x
+	nx  int
+	len uint64
+}
+
+func New() *Digest {
+	d := &Digest{}
+	d.Reset()
+	return d
+}
+
+// NewFromState resumes hashing as if processedLen bytes, a multiple of the block
+// size including padding, had already been compressed into the registers h
+func NewFromState(h [5]uint32, processedLen uint64) *Digest {
+	return &Digest{
+		h:   h,
+		len: processedLen,
+	}
+}
+
+// StateFromSum recovers the registers from a digest
+func StateFromSum(sum [Size]byte) [5]uint32 {
+	var h [5]uint32
+	for i := range h {
+		h[i] = binary.BigEndian.Uint32(sum[4*i:])
+	}
+	return h
+}
+
+// GluePadding is the padding SHA-1 appends to a message of msgLen bytes
+func GluePadding(msgLen uint64) []byte {
+	return mdpad.Padding(msgLen, binary.BigEndian)
+}
+
+func Sum(data []byte) [Size]byte {
+	d := New()
+	d.Write(data)
+	var out [Size]byte
+	copy(out[:], d.Sum(nil))
+	return out
+}
+
+func (d *Digest) Reset() {
+	d.h = initState
+	d.nx = 0
+	d.len = 0
+}
+
+func (d *Digest) Size() int { return Size }
+
+func (d *Digest) BlockSize() int { return BlockSize }
+
+func (d *Digest) Write(p []byte) (int, error) {
+	n := len(p)
+	d.len += uint64(n)
+	if d.nx > 0 {
+		c := copy(d.x[d.nx:], p)
+		d.nx += c
+		if d.nx == BlockSize {
+			block(&d.h, d.x[:])
+			d.nx = 0
+		}
+		p = p[c:]
+	}
+	for len(p) >= BlockSize {
+		block(&d.h, p[:BlockSize])
+		p = p[BlockSize:]
+	}
+	if len(p) > 0 {
+		d.nx = copy(d.x[:], p)
+	}
+	return n, nil
+}
+
+// Sum appends the digest to in without changing the state of d
+func (d *Digest) Sum(in []byte) []byte {
+	cp := *d
+	cp.Write(GluePadding(d.len))
+
+	out := make([]byte, Size)
+	for i, v := range cp.h {
+		binary.BigEndian.PutUint32(out[4*i:], v)
+	}
+	return append(in, out...)
+}
+
+func block(h *[5]uint32, p []byte) {
+	var w [80]uint32
+	for i := 0; i < 16; i++ {
+		w[i] = binary.BigEndian.Uint32(p[4*i:])
+	}
+	for i := 16; i < 80; i++ {
+		w[i] = bits.RotateLeft32(w[i-3]^w[i-8]^w[i-14]^w[i-16], 1)
+	}
+
+	a, b, c, d, e := h[0], h[1], h[2], h[3], h[4]
+	for i := 0; i < 80; i++ {
+		var f, k uint32
+		switch {
+		case i < 20:
+			f, k = (b&c)|(^b&d), 0x5a827999
+		case i < 40:
+			f, k = b^c^d, 0x6ed9eba1
+		case i < 60:
+			f, k = (b&c)|(b&d)|(c&d), 0x8f1bbcdc
+		default:
+			f, k = b^c^d, 0xca62c1d6
+		}
+		tmp := bits.RotateLeft32(a, 5) + f + e + k + w[i]
+		a, b, c, d, e = tmp, a, bits.RotateLeft32(b, 30), c, d
+	}
+
+	h[0] += a
+	h[1] += b
+	h[2] += c
+	h[3] += d
+	h[4] += e
+}
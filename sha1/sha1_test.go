@@ -0,0 +1,66 @@
+package sha1
+
+import (
+	stdsha1 "crypto/sha1"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSum(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "", want: "da39a3ee5e6b4b0d3255bfef95601890afd80709"},
+		{in: "abc", want: "a9993e364706816aba3e25717850c26c9cd0d89d"},
+		{in: "abcdbcdecdefdefgefghfghighijhijkijkljklmklmnlmnomnopnopq", want: "84983e441c3bd26ebaae4aa1f95129e5e54670f1"},
+	}
+	for _, tt := range tests {
+		got := Sum([]byte(tt.in))
+		assert.Equal(t, tt.want, hex.EncodeToString(got[:]), tt.in)
+	}
+
+	// every padding boundary, against the standard library
+	for i := 0; i < 3*BlockSize; i++ {
+		msg := []byte(strings.Repeat("x", i))
+		assert.Equal(t, stdsha1.Sum(msg), Sum(msg), "len %d", i)
+	}
+}
+
+func TestDigest_Write(t *testing.T) {
+	msg := []byte(strings.Repeat("streaming writes split across blocks ", 10))
+	d := New()
+	for i := 0; i < len(msg); i += 7 {
+		end := i + 7
+		if end > len(msg) {
+			end = len(msg)
+		}
+		_, err := d.Write(msg[i:end])
+		require.NoError(t, err)
+	}
+	want := stdsha1.Sum(msg)
+	assert.Equal(t, want[:], d.Sum(nil))
+	// Sum does not change the state
+	assert.Equal(t, want[:], d.Sum(nil))
+}
+
+func TestNewFromState(t *testing.T) {
+	msg := []byte("the original message")
+	ext := []byte("more")
+
+	sum := Sum(msg)
+	glue := GluePadding(uint64(len(msg)))
+	processed := uint64(len(msg) + len(glue))
+	require.Zero(t, processed%BlockSize)
+
+	d := NewFromState(StateFromSum(sum), processed)
+	d.Write(ext)
+
+	full := append(append(append([]byte{}, msg...), glue...), ext...)
+	want := stdsha1.Sum(full)
+	assert.Equal(t, want[:], d.Sum(nil))
+}
@@ -0,0 +1,195 @@
+package srp
+
+import (
+	"crypto/hmac"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+)
+
+var ErrPasswordNotFound = errors.New("password not in dictionary")
+
+// ZeroKeyLogin logs in as email without the password by sending A, a
+// multiple of N such as 0, N or 2N. the server computes S = (A * v^u)^b = 0
+func ZeroKeyLogin(t Transport, email string, A *big.Int) error {
+	salt, _, err := t.Hello(email, A)
+	if err != nil {
+		return err
+	}
+	return t.Verify(email, Proof(big.NewInt(0), salt))
+}
+
+// SimpleTransport carries simplified SRP, where B does not depend on the
+// password and u is random
+type SimpleTransport interface {
+	// Hello sends the email and A, and returns the salt, B and u
+	Hello(email string, A *big.Int) (salt []byte, B, u *big.Int, err error)
+	Verify(email string, proof []byte) error
+}
+
+// SimpleClient logs in with simplified SRP
+type SimpleClient struct {
+	params   *Params
+	Email    string
+	Password string
+}
+
+func NewSimpleClient(params *Params, email, password string) *SimpleClient {
+	return &SimpleClient{params: params, Email: email, Password: password}
+}
+
+func (c *SimpleClient) Login(t SimpleTransport) error {
+	p := c.params
+	a, err := randomInt(p.N)
+	if err != nil {
+		return err
+	}
+	A := new(big.Int).Exp(p.G, a, p.N)
+
+	salt, B, u, err := t.Hello(c.Email, A)
+	if err != nil {
+		return err
+	}
+
+	// S = B^(a + u * x) mod N
+	x := hashInt(salt, []byte(c.Password))
+	exp := new(big.Int).Mul(u, x)
+	exp.Add(exp, a)
+	S := new(big.Int).Exp(B, exp, p.N)
+	return t.Verify(c.Email, Proof(S, salt))
+}
+
+// SimpleServer is the honest simplified SRP server
+type SimpleServer struct {
+	params *Params
+
+	mu       sync.Mutex
+	users    map[string]user
+	sessions map[string]simpleSession
+}
+
+type simpleSession struct {
+	A *big.Int
+	b *big.Int
+	u *big.Int
+}
+
+func NewSimpleServer(params *Params) *SimpleServer {
+	return &SimpleServer{
+		params:   params,
+		users:    make(map[string]user),
+		sessions: make(map[string]simpleSession),
+	}
+}
+
+func (s *SimpleServer) Register(email, password string) error {
+	u, err := newUser(s.params, password)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.users[email] = u
+	return nil
+}
+
+func (s *SimpleServer) Hello(email string, A *big.Int) ([]byte, *big.Int, *big.Int, error) {
+	p := s.params
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	usr, ok := s.users[email]
+	if !ok {
+		return nil, nil, nil, fmt.Errorf("%w: %s", ErrUnknownUser, email)
+	}
+	b, err := randomInt(p.N)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	u, err := randomInt(new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	s.sessions[email] = simpleSession{A: A, b: b, u: u}
+	return usr.salt, new(big.Int).Exp(p.G, b, p.N), u, nil
+}
+
+func (s *SimpleServer) Verify(email string, proof []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	usr, ok := s.users[email]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownUser, email)
+	}
+	sess, ok := s.sessions[email]
+	if !ok {
+		return ErrNoSession
+	}
+	delete(s.sessions, email)
+
+	if !hmac.Equal(simpleServerProof(s.params, sess, usr.v, usr.salt), proof) {
+		return ErrInvalidProof
+	}
+	return nil
+}
+
+// simpleServerProof computes S = (A * v^u)^b mod N and the proof from it
+func simpleServerProof(p *Params, sess simpleSession, v *big.Int, salt []byte) []byte {
+	S := new(big.Int).Exp(v, sess.u, p.N)
+	S.Mul(S, sess.A)
+	S.Exp(S, sess.b, p.N)
+	return Proof(S, salt)
+}
+
+// SimpleMITM impersonates a simplified SRP server. it picks b, B, u and the
+// salt itself, so the client's proof can be checked against guessed
+// passwords offline
+type SimpleMITM struct {
+	params *Params
+	sess   simpleSession
+	salt   []byte
+	// Proofs are the captured proofs by email
+	Proofs map[string][]byte
+	as     map[string]*big.Int
+}
+
+func NewSimpleMITM(params *Params) *SimpleMITM {
+	return &SimpleMITM{
+		params: params,
+		// b = 1, u = 1 and an empty salt keep the offline work to one exponentiation per guess
+		sess:   simpleSession{b: big.NewInt(1), u: big.NewInt(1)},
+		salt:   []byte{},
+		Proofs: make(map[string][]byte),
+		as:     make(map[string]*big.Int),
+	}
+}
+
+func (m *SimpleMITM) Hello(email string, A *big.Int) ([]byte, *big.Int, *big.Int, error) {
+	m.as[email] = A
+	B := new(big.Int).Exp(m.params.G, m.sess.b, m.params.N)
+	return m.salt, B, m.sess.u, nil
+}
+
+// Verify records the proof and lets the client in, so it suspects nothing
+func (m *SimpleMITM) Verify(email string, proof []byte) error {
+	m.Proofs[email] = proof
+	return nil
+}
+
+// Crack tries every password in dictionary against the captured proof for email
+func (m *SimpleMITM) Crack(email string, dictionary []string) (string, error) {
+	proof, ok := m.Proofs[email]
+	if !ok {
+		return "", fmt.Errorf("%w: no proof captured for %s", ErrNoSession, email)
+	}
+	sess := m.sess
+	sess.A = m.as[email]
+	for _, pw := range dictionary {
+		x := hashInt(m.salt, []byte(pw))
+		v := new(big.Int).Exp(m.params.G, x, m.params.N)
+		if hmac.Equal(simpleServerProof(m.params, sess, v, m.salt), proof) {
+			return pw, nil
+		}
+	}
+	return "", ErrPasswordNotFound
+}
@@ -0,0 +1,72 @@
+package srp
+
+import (
+	"math/big"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// set 5 challenge 37
+func TestZeroKeyLogin(t *testing.T) {
+	params := NISTParams()
+	zeros := map[string]*big.Int{
+		"0":  big.NewInt(0),
+		"N":  new(big.Int).Set(params.N),
+		"2N": new(big.Int).Lsh(params.N, 1),
+	}
+
+	srv := NewServer(params)
+	require.NoError(t, srv.Register("alice@example.com", "correct horse battery staple"))
+	web := httptest.NewServer(srv.Handler())
+	defer web.Close()
+	tr := &HTTPTransport{URL: web.URL}
+
+	for name, A := range zeros {
+		t.Run(name, func(t *testing.T) {
+			require.NoError(t, ZeroKeyLogin(tr, "alice@example.com", A))
+			require.NoError(t, ZeroKeyLogin(srv, "alice@example.com", A))
+		})
+	}
+
+	t.Run("checked", func(t *testing.T) {
+		checked := NewServer(params)
+		checked.CheckA = true
+		require.NoError(t, checked.Register("alice@example.com", "correct horse battery staple"))
+		for _, A := range zeros {
+			require.ErrorIs(t, ZeroKeyLogin(checked, "alice@example.com", A), ErrInvalidA)
+		}
+		require.NoError(t, NewClient(params, "alice@example.com", "correct horse battery staple").Login(checked))
+	})
+}
+
+// set 5 challenge 38
+func TestSimpleMITM(t *testing.T) {
+	params := NISTParams()
+	dictionary := []string{"password", "123456", "letmein", "dragon", "monkey", "sunshine", "trustno1"}
+
+	t.Run("honest", func(t *testing.T) {
+		srv := NewSimpleServer(params)
+		require.NoError(t, srv.Register("alice@example.com", "dragon"))
+		require.NoError(t, NewSimpleClient(params, "alice@example.com", "dragon").Login(srv))
+		require.ErrorIs(t, NewSimpleClient(params, "alice@example.com", "monkey").Login(srv), ErrInvalidProof)
+	})
+
+	t.Run("crack", func(t *testing.T) {
+		mitm := NewSimpleMITM(params)
+		require.NoError(t, NewSimpleClient(params, "alice@example.com", "sunshine").Login(mitm))
+
+		got, err := mitm.Crack("alice@example.com", dictionary)
+		require.NoError(t, err)
+		assert.Equal(t, "sunshine", got)
+	})
+
+	t.Run("not in dictionary", func(t *testing.T) {
+		mitm := NewSimpleMITM(params)
+		require.NoError(t, NewSimpleClient(params, "alice@example.com", "Tr0ub4dor&3").Login(mitm))
+		_, err := mitm.Crack("alice@example.com", dictionary)
+		require.ErrorIs(t, err, ErrPasswordNotFound)
+	})
+}
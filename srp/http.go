@@ -0,0 +1,115 @@
+package srp
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+)
+
+type helloRequest struct {
+	Email string   `json:"email"`
+	A     *big.Int `json:"A"`
+}
+
+type helloResponse struct {
+	Salt []byte   `json:"salt"`
+	B    *big.Int `json:"B"`
+}
+
+type verifyRequest struct {
+	Email string `json:"email"`
+	Proof []byte `json:"proof"`
+}
+
+// Handler serves the server on /hello and /verify, e.g. with httptest
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/hello", func(w http.ResponseWriter, r *http.Request) {
+		var req helloRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.A == nil {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		salt, B, err := s.Hello(req.Email, req.A)
+		if err != nil {
+			http.Error(w, err.Error(), statusFor(err))
+			return
+		}
+		json.NewEncoder(w).Encode(helloResponse{Salt: salt, B: B})
+	})
+	mux.HandleFunc("/verify", func(w http.ResponseWriter, r *http.Request) {
+		var req verifyRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		if err := s.Verify(req.Email, req.Proof); err != nil {
+			http.Error(w, err.Error(), statusFor(err))
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	return mux
+}
+
+func statusFor(err error) int {
+	switch {
+	case errors.Is(err, ErrUnknownUser), errors.Is(err, ErrInvalidProof), errors.Is(err, ErrNoSession):
+		return http.StatusUnauthorized
+	case errors.Is(err, ErrInvalidA):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// ErrUnauthorized is returned by HTTPTransport for any login failure
+var ErrUnauthorized = errors.New("unauthorized")
+
+// HTTPTransport is a Transport to a Server's Handler
+type HTTPTransport struct {
+	URL    string
+	Client *http.Client
+}
+
+func (t *HTTPTransport) Hello(email string, A *big.Int) ([]byte, *big.Int, error) {
+	var resp helloResponse
+	if err := t.post("/hello", helloRequest{Email: email, A: A}, &resp); err != nil {
+		return nil, nil, err
+	}
+	return resp.Salt, resp.B, nil
+}
+
+func (t *HTTPTransport) Verify(email string, proof []byte) error {
+	return t.post("/verify", verifyRequest{Email: email, Proof: proof}, nil)
+}
+
+func (t *HTTPTransport) post(path string, req, resp any) error {
+	client := t.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	r, err := client.Post(t.URL+path, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer r.Body.Close()
+	if r.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(r.Body)
+		if r.StatusCode == http.StatusUnauthorized {
+			return fmt.Errorf("%w: %s", ErrUnauthorized, bytes.TrimSpace(msg))
+		}
+		return fmt.Errorf("%s: %d %s", path, r.StatusCode, bytes.TrimSpace(msg))
+	}
+	if resp == nil {
+		return nil
+	}
+	return json.NewDecoder(r.Body).Decode(resp)
+}
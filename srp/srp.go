@@ -0,0 +1,227 @@
+// Package srp is Secure Remote Password (SRP-6a) over SHA-256, with the
+// client proving knowledge of the session key with an HMAC of the salt
+package srp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/krehermann/go-cryptopals/dh"
+)
+
+var (
+	ErrUnknownUser  = errors.New("unknown user")
+	ErrInvalidProof = errors.New("invalid proof")
+	ErrInvalidA     = errors.New("invalid client public value")
+	ErrInvalidB     = errors.New("invalid server public value")
+	ErrNoSession    = errors.New("no session, say hello first")
+)
+
+// Params are the group, and the multiplier k = H(N || g)
+type Params struct {
+	N *big.Int
+	G *big.Int
+	K *big.Int
+}
+
+// NISTParams uses the cryptopals NIST prime with g = 2
+func NISTParams() *Params {
+	g := dh.NISTGroup()
+	return &Params{
+		N: g.P,
+		G: g.G,
+		K: hashInt(g.P.Bytes(), g.G.Bytes()),
+	}
+}
+
+// Transport carries the two round trips of the protocol
+type Transport interface {
+	// Hello sends the email and A, and returns the salt and B
+	Hello(email string, A *big.Int) (salt []byte, B *big.Int, err error)
+	// Verify sends the proof HMAC-SHA256(K, salt)
+	Verify(email string, proof []byte) error
+}
+
+type Client struct {
+	params   *Params
+	Email    string
+	Password string
+}
+
+func NewClient(params *Params, email, password string) *Client {
+	return &Client{params: params, Email: email, Password: password}
+}
+
+// Login runs the protocol over t
+func (c *Client) Login(t Transport) error {
+	p := c.params
+	a, err := randomInt(p.N)
+	if err != nil {
+		return err
+	}
+	A := new(big.Int).Exp(p.G, a, p.N)
+
+	salt, B, err := t.Hello(c.Email, A)
+	if err != nil {
+		return err
+	}
+	// the client's side of the A check: with B = 0 mod N, S no longer
+	// depends on the verifier, so a fake server learns a proof it can test
+	// password guesses against
+	if B == nil || new(big.Int).Mod(B, p.N).Sign() == 0 {
+		return ErrInvalidB
+	}
+
+	u := hashInt(A.Bytes(), B.Bytes())
+	x := hashInt(salt, []byte(c.Password))
+
+	// S = (B - k * g^x)^(a + u * x) mod N
+	base := new(big.Int).Exp(p.G, x, p.N)
+	base.Mul(base, p.K)
+	base.Sub(B, base)
+	base.Mod(base, p.N)
+	exp := new(big.Int).Mul(u, x)
+	exp.Add(exp, a)
+	S := new(big.Int).Exp(base, exp, p.N)
+
+	return t.Verify(c.Email, Proof(S, salt))
+}
+
+// Proof is HMAC-SHA256(SHA256(S), salt)
+func Proof(S *big.Int, salt []byte) []byte {
+	K := sha256.Sum256(S.Bytes())
+	m := hmac.New(sha256.New, K[:])
+	m.Write(salt)
+	return m.Sum(nil)
+}
+
+type user struct {
+	salt []byte
+	v    *big.Int
+}
+
+type session struct {
+	A *big.Int
+	b *big.Int
+	B *big.Int
+}
+
+// Server is an in memory Transport. see Handler to serve it over HTTP
+type Server struct {
+	params *Params
+	// CheckA rejects A = 0 mod N. without it, anyone can log in as anyone
+	CheckA bool
+
+	mu       sync.Mutex
+	users    map[string]user
+	sessions map[string]session
+}
+
+func NewServer(params *Params) *Server {
+	return &Server{
+		params:   params,
+		users:    make(map[string]user),
+		sessions: make(map[string]session),
+	}
+}
+
+// Register stores the salt and verifier v = g^x for a user. the password is not kept
+func (s *Server) Register(email, password string) error {
+	u, err := newUser(s.params, password)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.users[email] = u
+	return nil
+}
+
+func newUser(p *Params, password string) (user, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return user{}, err
+	}
+	x := hashInt(salt, []byte(password))
+	return user{
+		salt: salt,
+		v:    new(big.Int).Exp(p.G, x, p.N),
+	}, nil
+}
+
+func (s *Server) Hello(email string, A *big.Int) ([]byte, *big.Int, error) {
+	p := s.params
+	if A == nil {
+		return nil, nil, ErrInvalidA
+	}
+	if s.CheckA && new(big.Int).Mod(A, p.N).Sign() == 0 {
+		return nil, nil, ErrInvalidA
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u, ok := s.users[email]
+	if !ok {
+		return nil, nil, fmt.Errorf("%w: %s", ErrUnknownUser, email)
+	}
+
+	b, err := randomInt(p.N)
+	if err != nil {
+		return nil, nil, err
+	}
+	// B = k * v + g^b mod N
+	B := new(big.Int).Mul(p.K, u.v)
+	B.Add(B, new(big.Int).Exp(p.G, b, p.N))
+	B.Mod(B, p.N)
+
+	s.sessions[email] = session{A: A, b: b, B: B}
+	return u.salt, B, nil
+}
+
+func (s *Server) Verify(email string, proof []byte) error {
+	p := s.params
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u, ok := s.users[email]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownUser, email)
+	}
+	sess, ok := s.sessions[email]
+	if !ok {
+		return ErrNoSession
+	}
+	delete(s.sessions, email)
+
+	uH := hashInt(sess.A.Bytes(), sess.B.Bytes())
+	// S = (A * v^u)^b mod N
+	S := new(big.Int).Exp(u.v, uH, p.N)
+	S.Mul(S, sess.A)
+	S.Exp(S, sess.b, p.N)
+
+	if !hmac.Equal(Proof(S, u.salt), proof) {
+		return ErrInvalidProof
+	}
+	return nil
+}
+
+func hashInt(parts ...[]byte) *big.Int {
+	h := sha256.New()
+	for _, p := range parts {
+		h.Write(p)
+	}
+	return new(big.Int).SetBytes(h.Sum(nil))
+}
+
+// randomInt is in [1, max)
+func randomInt(max *big.Int) (*big.Int, error) {
+	n, err := rand.Int(rand.Reader, new(big.Int).Sub(max, big.NewInt(1)))
+	if err != nil {
+		return nil, err
+	}
+	return n.Add(n, big.NewInt(1)), nil
+}
@@ -0,0 +1,84 @@
+package srp
+
+import (
+	"math/big"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// set 5 challenge 36
+func TestLogin(t *testing.T) {
+	params := NISTParams()
+	srv := NewServer(params)
+	require.NoError(t, srv.Register("alice@example.com", "hunter2"))
+
+	web := httptest.NewServer(srv.Handler())
+	defer web.Close()
+
+	transports := map[string]Transport{
+		"memory": srv,
+		"http":   &HTTPTransport{URL: web.URL},
+	}
+	for name, tr := range transports {
+		t.Run(name, func(t *testing.T) {
+			require.NoError(t, NewClient(params, "alice@example.com", "hunter2").Login(tr))
+			require.Error(t, NewClient(params, "alice@example.com", "hunter3").Login(tr))
+			require.Error(t, NewClient(params, "bob@example.com", "hunter2").Login(tr))
+		})
+	}
+
+	t.Run("wrong password", func(t *testing.T) {
+		err := NewClient(params, "alice@example.com", "hunter3").Login(srv)
+		require.ErrorIs(t, err, ErrInvalidProof)
+		err = NewClient(params, "alice@example.com", "hunter3").Login(transports["http"])
+		require.ErrorIs(t, err, ErrUnauthorized)
+	})
+
+	t.Run("verify without hello", func(t *testing.T) {
+		require.ErrorIs(t, srv.Verify("alice@example.com", []byte("proof")), ErrNoSession)
+	})
+
+	t.Run("nil A", func(t *testing.T) {
+		_, _, err := srv.Hello("alice@example.com", nil)
+		require.ErrorIs(t, err, ErrInvalidA)
+		srv.CheckA = true
+		defer func() { srv.CheckA = false }()
+		_, _, err = srv.Hello("alice@example.com", nil)
+		require.ErrorIs(t, err, ErrInvalidA)
+	})
+}
+
+// fixedB is a malicious server that answers every hello with B
+type fixedB struct {
+	B        *big.Int
+	verified bool
+}
+
+func (f *fixedB) Hello(string, *big.Int) ([]byte, *big.Int, error) {
+	return []byte("salt"), f.B, nil
+}
+
+func (f *fixedB) Verify(string, []byte) error {
+	f.verified = true
+	return nil
+}
+
+func TestLogin_ZeroB(t *testing.T) {
+	params := NISTParams()
+	for name, B := range map[string]*big.Int{
+		"nil": nil,
+		"0":   big.NewInt(0),
+		"N":   new(big.Int).Set(params.N),
+		"2N":  new(big.Int).Lsh(params.N, 1),
+	} {
+		t.Run(name, func(t *testing.T) {
+			tr := &fixedB{B: B}
+			err := NewClient(params, "alice@example.com", "hunter2").Login(tr)
+			require.ErrorIs(t, err, ErrInvalidB)
+			assert.False(t, tr.verified, "client sent a proof")
+		})
+	}
+}
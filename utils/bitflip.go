@@ -0,0 +1,118 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+)
+
+const (
+	commentPrefix = "comment1=cooking%20MCs;userdata="
+	commentSuffix = ";comment2=%20like%20a%20pound%20of%20bacon"
+)
+
+var adminToken = []byte(";admin=true;")
+
+// CommentOracle encrypts user data inside a fixed comment string, quoting
+// out the characters that would let it inject ;admin=true; directly
+type CommentOracle struct {
+	aes *AES
+}
+
+// NewCommentOracle uses a random key, and a random iv for AESCBC
+func NewCommentOracle(mode AESMode) (*CommentOracle, error) {
+	key, err := randomBytes(16)
+	if err != nil {
+		return nil, err
+	}
+	var opts []AESOpt
+	if mode == AESCBC {
+		iv, err := randomBytes(16)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithIV(iv))
+	}
+	a, err := NewAES(key, mode, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &CommentOracle{aes: a}, nil
+}
+
+// Encrypt encrypts prefix || quote(userdata) || suffix
+func (o *CommentOracle) Encrypt(userdata []byte) ([]byte, error) {
+	quoted := bytes.ReplaceAll(userdata, []byte(";"), []byte("%3B"))
+	quoted = bytes.ReplaceAll(quoted, []byte("="), []byte("%3D"))
+
+	txt := make([]byte, 0, len(commentPrefix)+len(quoted)+len(commentSuffix))
+	txt = append(txt, commentPrefix...)
+	txt = append(txt, quoted...)
+	txt = append(txt, commentSuffix...)
+	return o.aes.Encrypt(txt)
+}
+
+// IsAdmin decrypts ciphertext and looks for ;admin=true;
+func (o *CommentOracle) IsAdmin(ciphertext []byte) (bool, error) {
+	txt, err := o.aes.Decrypt(ciphertext)
+	if err != nil {
+		return false, err
+	}
+	return bytes.Contains(txt, adminToken), nil
+}
+
+// CBCBitFlip returns a copy of ciphertext where the bytes at offset in block
+// decrypt to target instead of known. the preceding block, which decrypts to
+// garbage, absorbs the change
+func CBCBitFlip(ciphertext []byte, blockSize, block, offset int, known, target []byte) ([]byte, error) {
+	if block < 1 {
+		return nil, fmt.Errorf("cannot flip block %d, it has no preceding block", block)
+	}
+	if offset < 0 || offset+len(known) > blockSize {
+		return nil, fmt.Errorf("%d bytes at offset %d do not fit in a %d byte block", len(known), offset, blockSize)
+	}
+	if len(ciphertext) < (block+1)*blockSize {
+		return nil, fmt.Errorf("ciphertext has no block %d", block)
+	}
+	return xorAt(ciphertext, (block-1)*blockSize+offset, known, target)
+}
+
+// xorAt xors known ^ target into a copy of ciphertext at pos
+func xorAt(ciphertext []byte, pos int, known, target []byte) ([]byte, error) {
+	mask, err := FixedXor(known, target)
+	if err != nil {
+		return nil, err
+	}
+	out := append([]byte{}, ciphertext...)
+	for i, b := range mask {
+		out[pos+i] ^= b
+	}
+	return out, nil
+}
+
+// ForgeCBCAdmin gets ;admin=true; past a CBC CommentOracle. it sends a
+// sacrificial block followed by a block holding the token with ; and =
+// replaced, then flips the sacrificial block to restore them
+func ForgeCBCAdmin(oracle *CommentOracle) ([]byte, error) {
+	blockSize := oracle.aes.BlockSize()
+	alignPad := (blockSize - len(commentPrefix)%blockSize) % blockSize
+	known := defangedAdminToken()
+
+	input := bytes.Repeat([]byte{'A'}, alignPad+blockSize)
+	input = append(input, known...)
+	enc, err := oracle.Encrypt(input)
+	if err != nil {
+		return nil, err
+	}
+	block := (len(commentPrefix)+alignPad)/blockSize + 1
+	return CBCBitFlip(enc, blockSize, block, 0, known, adminToken)
+}
+
+// defangedAdminToken is ;admin=true; with the characters the oracle quotes replaced
+func defangedAdminToken() []byte {
+	return bytes.Map(func(r rune) rune {
+		if r == ';' || r == '=' {
+			return 'X'
+		}
+		return r
+	}, adminToken)
+}
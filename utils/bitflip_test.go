@@ -0,0 +1,90 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommentOracle(t *testing.T) {
+	oracle, err := NewCommentOracle(AESCBC)
+	require.NoError(t, err)
+
+	t.Run("quotes metacharacters", func(t *testing.T) {
+		enc, err := oracle.Encrypt([]byte(";admin=true;"))
+		require.NoError(t, err)
+		admin, err := oracle.IsAdmin(enc)
+		require.NoError(t, err)
+		assert.False(t, admin)
+
+		txt, err := oracle.aes.Decrypt(enc)
+		require.NoError(t, err)
+		assert.Equal(t, commentPrefix+"%3Badmin%3Dtrue%3B"+commentSuffix, string(txt))
+	})
+
+	t.Run("bad padding", func(t *testing.T) {
+		enc, err := oracle.Encrypt([]byte("hello"))
+		require.NoError(t, err)
+		// the last byte of the penultimate block sets the final padding byte.
+		// most of its 256 values give invalid padding
+		bad := append([]byte{}, enc...)
+		pos := len(bad) - 17
+		for g := 0; g < 256; g++ {
+			bad[pos] = enc[pos] ^ byte(g)
+			if _, err = oracle.IsAdmin(bad); err != nil {
+				break
+			}
+		}
+		require.ErrorIs(t, err, ErrInvalidPKCS7)
+	})
+
+	t.Run("wrong length", func(t *testing.T) {
+		_, err := oracle.IsAdmin(make([]byte, 15))
+		require.ErrorIs(t, err, ErrCiphertextLength)
+	})
+}
+
+// set 2 challenge 16
+func TestForgeCBCAdmin(t *testing.T) {
+	oracle, err := NewCommentOracle(AESCBC)
+	require.NoError(t, err)
+
+	forged, err := ForgeCBCAdmin(oracle)
+	require.NoError(t, err)
+	admin, err := oracle.IsAdmin(forged)
+	require.NoError(t, err)
+	assert.True(t, admin)
+}
+
+func TestCBCBitFlip(t *testing.T) {
+	key := []byte("YELLOW SUBMARINE")
+	a, err := NewAES(key, AESCBC)
+	require.NoError(t, err)
+	enc, err := a.Encrypt([]byte("0123456789abcdefhello world!...."))
+	require.NoError(t, err)
+
+	flipped, err := CBCBitFlip(enc, 16, 1, 6, []byte("world"), []byte("there"))
+	require.NoError(t, err)
+	txt, err := a.Decrypt(flipped)
+	require.NoError(t, err)
+	assert.Equal(t, "hello there!....", string(txt[16:]))
+	assert.NotEqual(t, enc, flipped)
+
+	tests := []struct {
+		name          string
+		block, offset int
+		known, target []byte
+	}{
+		{name: "first block", block: 0, known: []byte("a"), target: []byte("b")},
+		{name: "past end", block: 3, known: []byte("a"), target: []byte("b")},
+		{name: "overflows block", block: 1, offset: 14, known: []byte("abc"), target: []byte("def")},
+		{name: "length mismatch", block: 1, known: []byte("ab"), target: []byte("c")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := CBCBitFlip(enc, 16, tt.block, tt.offset, tt.known, tt.target)
+			require.Error(t, err)
+		})
+	}
+}
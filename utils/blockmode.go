@@ -0,0 +1,209 @@
+package utils
+
+import (
+	"crypto/cipher"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// BlockMode runs ECB, CBC or CTR over any cipher.Block, so the same
+// mode logic (and the same attacks) apply to DES, 3DES or a toy cipher
+type BlockMode struct {
+	ciphr cipher.Block
+	mode  AESMode
+	// optional
+	cbcIV     []byte
+	ivSet     bool
+	ctrNonce  []byte
+	ctrLayout CounterLayout
+}
+
+// ErrCiphertextLength is returned when ECB or CBC ciphertext is not made of
+// whole blocks
+var ErrCiphertextLength = errors.New("invalid ciphertext length")
+
+type ModeOpt func(*BlockMode)
+
+// AESOpt configures the mode of an AES instance
+type AESOpt = ModeOpt
+
+func WithIV(iv []byte) ModeOpt {
+	return func(m *BlockMode) {
+		m.cbcIV = iv
+		m.ivSet = true
+	}
+}
+
+// WithNonce sets the nonce that fills the first half of each CTR counter block
+func WithNonce(nonce []byte) ModeOpt {
+	return func(m *BlockMode) {
+		m.ctrNonce = nonce
+	}
+}
+
+// WithCounterLayout sets the byte order of the 64 bit CTR block counter
+func WithCounterLayout(l CounterLayout) ModeOpt {
+	return func(m *BlockMode) {
+		m.ctrLayout = l
+	}
+}
+
+// CounterLayout is the byte order of the CTR block counter.
+type CounterLayout int
+
+const (
+	// LittleEndianCounter is the layout specified by cryptopals
+	LittleEndianCounter CounterLayout = iota
+	// BigEndianCounter matches crypto/cipher.NewCTR with iv = nonce || 0
+	BigEndianCounter
+)
+
+// ctrCounterLen is the number of bytes at the end of the counter block
+// holding the counter. the remainder is the nonce
+const ctrCounterLen = 8
+
+// putCounter writes the low len(dst) bytes of counter into dst
+func putCounter(dst []byte, counter uint64, layout CounterLayout) {
+	buf := make([]byte, ctrCounterLen)
+	switch layout {
+	case BigEndianCounter:
+		binary.BigEndian.PutUint64(buf, counter)
+		copy(dst, buf[ctrCounterLen-len(dst):])
+	default:
+		binary.LittleEndian.PutUint64(buf, counter)
+		copy(dst, buf)
+	}
+}
+
+func NewBlockMode(c cipher.Block, mode AESMode, opts ...ModeOpt) (*BlockMode, error) {
+	blockSize := c.BlockSize()
+	nonceLen := blockSize - ctrCounterLen
+	if nonceLen < 0 {
+		nonceLen = 0
+	}
+
+	m := &BlockMode{
+		ciphr:    c,
+		mode:     mode,
+		cbcIV:    make([]byte, blockSize),
+		ctrNonce: make([]byte, nonceLen),
+	}
+
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	switch mode {
+	case AESECB:
+	case AESCBC:
+		if len(m.cbcIV) != blockSize {
+			return nil, fmt.Errorf("cbc iv must be %d bytes, got %d", blockSize, len(m.cbcIV))
+		}
+	case AESCTR:
+		if len(m.ctrNonce) != nonceLen {
+			return nil, fmt.Errorf("ctr nonce must be %d bytes, got %d", nonceLen, len(m.ctrNonce))
+		}
+	default:
+		return nil, fmt.Errorf("unknown mode %d", mode)
+	}
+	return m, nil
+}
+
+// BlockSize is the block size of the underlying cipher
+func (m *BlockMode) BlockSize() int {
+	return m.ciphr.BlockSize()
+}
+
+func (m *BlockMode) Encrypt(src []byte) ([]byte, error) {
+	if m.mode == AESCTR {
+		return m.ctr(src), nil
+	}
+
+	blockSize := m.ciphr.BlockSize()
+	// always pad, a full block of padding when src is aligned. without it
+	// Decrypt could not tell an aligned message ending in 0x01 from padding
+	padLen := blockSize - len(src)%blockSize
+	src = PKCS7(src, len(src)+padLen)
+	result := make([]byte, len(src))
+
+	switch m.mode {
+	case AESECB:
+		for start, end := 0, blockSize; end <= len(src); start, end = start+blockSize, end+blockSize {
+			m.ciphr.Encrypt(result[start:end], src[start:end])
+		}
+	case AESCBC:
+		prevBlock := m.cbcIV
+		for start, end := 0, blockSize; end <= len(src); start, end = start+blockSize, end+blockSize {
+			transformed, err := FixedXor(prevBlock, src[start:end])
+			if err != nil {
+				return nil, err
+			}
+			m.ciphr.Encrypt(result[start:end], transformed)
+			prevBlock = result[start:end]
+		}
+	}
+	return result, nil
+
+}
+
+func (m *BlockMode) Decrypt(src []byte) ([]byte, error) {
+	if m.mode == AESCTR {
+		return m.ctr(src), nil
+	}
+
+	blockSize := m.ciphr.BlockSize()
+	// Encrypt always pads to at least one whole block
+	if len(src) == 0 || len(src)%blockSize != 0 {
+		return nil, fmt.Errorf("%w: %d bytes is not a whole number of %d byte blocks", ErrCiphertextLength, len(src), blockSize)
+	}
+	result := make([]byte, len(src))
+
+	switch m.mode {
+	case AESECB:
+		for start, end := 0, blockSize; end <= len(src); start, end = start+blockSize, end+blockSize {
+			m.ciphr.Decrypt(result[start:end], src[start:end])
+		}
+	case AESCBC:
+		prevBlock := m.cbcIV
+		tmp := make([]byte, m.ciphr.BlockSize())
+
+		for start, end := 0, blockSize; end <= len(src); start, end = start+blockSize, end+blockSize {
+			m.ciphr.Decrypt(tmp, src[start:end])
+
+			transformed, err := FixedXor(prevBlock, tmp)
+			if err != nil {
+				return nil, err
+			}
+			copy(result[start:end], transformed)
+			prevBlock = src[start:end]
+		}
+	}
+
+	// drop padding
+	return truncatePKCS7(result, blockSize)
+
+}
+
+// ctr xors src with the keystream. encryption and decryption are the same operation
+func (m *BlockMode) ctr(src []byte) []byte {
+	blockSize := m.ciphr.BlockSize()
+	result := make([]byte, len(src))
+	counterBlock := make([]byte, blockSize)
+	copy(counterBlock, m.ctrNonce)
+	keyStream := make([]byte, blockSize)
+
+	for start, counter := 0, uint64(0); start < len(src); start, counter = start+blockSize, counter+1 {
+		putCounter(counterBlock[len(m.ctrNonce):], counter, m.ctrLayout)
+		m.ciphr.Encrypt(keyStream, counterBlock)
+
+		end := start + blockSize
+		if end > len(src) {
+			end = len(src)
+		}
+		for i := start; i < end; i++ {
+			result[i] = src[i] ^ keyStream[i-start]
+		}
+	}
+	return result
+}
@@ -0,0 +1,142 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"crypto/des"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// toyCipher is an insecure 8 byte block cipher: add the key and rotate
+type toyCipher struct {
+	key [8]byte
+}
+
+func (c *toyCipher) BlockSize() int { return 8 }
+
+func (c *toyCipher) Encrypt(dst, src []byte) {
+	var tmp [8]byte
+	for i := 0; i < 8; i++ {
+		tmp[(i+1)%8] = src[i] + c.key[i]
+	}
+	copy(dst, tmp[:])
+}
+
+func (c *toyCipher) Decrypt(dst, src []byte) {
+	var tmp [8]byte
+	for i := 0; i < 8; i++ {
+		tmp[i] = src[(i+1)%8] - c.key[i]
+	}
+	copy(dst, tmp[:])
+}
+
+func testBlocks(t *testing.T) map[string]cipher.Block {
+	d, err := des.NewCipher([]byte("8bytekey"))
+	require.NoError(t, err)
+	tripleD, err := des.NewTripleDESCipher([]byte("twenty four byte key!!!!"))
+	require.NoError(t, err)
+	return map[string]cipher.Block{
+		"des":  d,
+		"3des": tripleD,
+		"toy":  &toyCipher{key: [8]byte{1, 2, 3, 4, 5, 6, 7, 8}},
+	}
+}
+
+func TestBlockMode_RoundTrip(t *testing.T) {
+	msg := []byte("modes are independent of the block cipher underneath")
+	for name, block := range testBlocks(t) {
+		for _, mode := range []AESMode{AESECB, AESCBC, AESCTR} {
+			t.Run(fmt.Sprintf("%s mode %d", name, mode), func(t *testing.T) {
+				m, err := NewBlockMode(block, mode)
+				require.NoError(t, err)
+				enc, err := m.Encrypt(msg)
+				require.NoError(t, err)
+				assert.NotEqual(t, msg, enc[:len(msg)])
+				got, err := m.Decrypt(enc)
+				require.NoError(t, err)
+				assert.Equal(t, msg, got)
+			})
+		}
+	}
+
+	t.Run("des ctr matches cipher.NewCTR", func(t *testing.T) {
+		block := testBlocks(t)["des"]
+		m, err := NewBlockMode(block, AESCTR, WithCounterLayout(BigEndianCounter))
+		require.NoError(t, err)
+		got, err := m.Encrypt(msg)
+		require.NoError(t, err)
+
+		want := make([]byte, len(msg))
+		cipher.NewCTR(block, make([]byte, block.BlockSize())).XORKeyStream(want, msg)
+		assert.Equal(t, want, got)
+	})
+
+	t.Run("partial block", func(t *testing.T) {
+		for _, mode := range []AESMode{AESECB, AESCBC} {
+			m, err := NewBlockMode(testBlocks(t)["des"], mode)
+			require.NoError(t, err)
+			enc, err := m.Encrypt(msg)
+			require.NoError(t, err)
+			for _, ct := range [][]byte{nil, enc[:len(enc)-1], append(enc, 0)} {
+				_, err = m.Decrypt(ct)
+				require.ErrorIs(t, err, ErrCiphertextLength, "mode %d, %d bytes", mode, len(ct))
+			}
+		}
+	})
+
+	t.Run("bad iv", func(t *testing.T) {
+		_, err := NewBlockMode(testBlocks(t)["des"], AESCBC, WithIV(make([]byte, 16)))
+		require.Error(t, err)
+	})
+}
+
+func TestBlockMode_DetectECB(t *testing.T) {
+	msg := bytes.Repeat([]byte("YELLOW SUBMARINE"), 4)
+	for name, block := range testBlocks(t) {
+		t.Run(name, func(t *testing.T) {
+			ecb, err := NewBlockMode(block, AESECB)
+			require.NoError(t, err)
+			enc, err := ecb.Encrypt(msg)
+			require.NoError(t, err)
+			score, _ := DetectAES128ECB(enc, ecb.BlockSize())
+			assert.Greater(t, score, float64(0))
+
+			cbc, err := NewBlockMode(block, AESCBC)
+			require.NoError(t, err)
+			enc, err = cbc.Encrypt(msg)
+			require.NoError(t, err)
+			score, _ = DetectAES128ECB(enc, cbc.BlockSize())
+			assert.Equal(t, float64(0), score)
+		})
+	}
+}
+
+func TestBlockMode_PaddingOracle(t *testing.T) {
+	msg := []byte("the padding oracle only cares about CBC")
+	for name, block := range testBlocks(t) {
+		t.Run(name, func(t *testing.T) {
+			iv := []byte("initvect")
+			m, err := NewBlockMode(block, AESCBC, WithIV(iv))
+			require.NoError(t, err)
+			enc, err := m.Encrypt(msg)
+			require.NoError(t, err)
+
+			oracle := func(iv, ciphertext []byte) bool {
+				m, err := NewBlockMode(block, AESCBC, WithIV(iv))
+				if err != nil {
+					return false
+				}
+				_, err = m.Decrypt(ciphertext)
+				return err == nil
+			}
+			attacker := NewPaddingOracleAttacker(oracle, block.BlockSize())
+			got, err := attacker.Decrypt(iv, enc)
+			require.NoError(t, err)
+			assert.Equal(t, string(msg), string(got))
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package utils
+
+import "fmt"
+
+// Edit re-encrypts AES-CTR ciphertext under key so that it decrypts to
+// newtext at offset, without touching the rest. opts pick the nonce and
+// counter layout the ciphertext was made with. the ciphertext grows if
+// newtext runs past its end
+func Edit(ciphertext, key []byte, offset int, newtext []byte, opts ...AESOpt) ([]byte, error) {
+	if offset < 0 || offset > len(ciphertext) {
+		return nil, fmt.Errorf("offset %d outside ciphertext of %d bytes", offset, len(ciphertext))
+	}
+	a, err := NewAES(key, AESCTR, opts...)
+	if err != nil {
+		return nil, err
+	}
+	end := offset + len(newtext)
+	// the keystream is the encryption of zeros
+	keyStream := a.ctr(make([]byte, end))
+
+	out := make([]byte, len(ciphertext))
+	copy(out, ciphertext)
+	if end > len(out) {
+		out = append(out, make([]byte, end-len(out))...)
+	}
+	for i, b := range newtext {
+		out[offset+i] = b ^ keyStream[offset+i]
+	}
+	return out, nil
+}
+
+// CTREditOracle is seekable storage: it encrypts under a secret key and
+// lets anyone rewrite part of a ciphertext
+type CTREditOracle struct {
+	key   []byte
+	nonce []byte
+}
+
+func NewCTREditOracle() (*CTREditOracle, error) {
+	key, err := randomBytes(16)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := randomBytes(16 - ctrCounterLen)
+	if err != nil {
+		return nil, err
+	}
+	return &CTREditOracle{key: key, nonce: nonce}, nil
+}
+
+func (o *CTREditOracle) Encrypt(txt []byte) ([]byte, error) {
+	a, err := NewAES(o.key, AESCTR, WithNonce(o.nonce))
+	if err != nil {
+		return nil, err
+	}
+	return a.Encrypt(txt)
+}
+
+func (o *CTREditOracle) Edit(ciphertext []byte, offset int, newtext []byte) ([]byte, error) {
+	return Edit(ciphertext, o.key, offset, newtext, WithNonce(o.nonce))
+}
+
+// EditFunc is an Edit call with the key bound in, as CTREditOracle.Edit
+type EditFunc func(ciphertext []byte, offset int, newtext []byte) ([]byte, error)
+
+// RecoverCTRWithEdit decrypts ciphertext using only the edit API: writing
+// zeros over the whole ciphertext returns the raw keystream
+func RecoverCTRWithEdit(ciphertext []byte, edit EditFunc) ([]byte, error) {
+	keyStream, err := edit(ciphertext, 0, make([]byte, len(ciphertext)))
+	if err != nil {
+		return nil, err
+	}
+	return FixedXor(ciphertext, keyStream)
+}
+
+// CTRBitFlip returns a copy of ciphertext where the bytes at offset decrypt
+// to target instead of known. unlike CBC nothing else changes
+func CTRBitFlip(ciphertext []byte, offset int, known, target []byte) ([]byte, error) {
+	if offset < 0 || offset+len(known) > len(ciphertext) {
+		return nil, fmt.Errorf("%d bytes at offset %d outside ciphertext of %d bytes", len(known), offset, len(ciphertext))
+	}
+	return xorAt(ciphertext, offset, known, target)
+}
+
+// ForgeCTRAdmin gets ;admin=true; past a CTR CommentOracle by sending the
+// token with ; and = replaced and flipping them back in place
+func ForgeCTRAdmin(oracle *CommentOracle) ([]byte, error) {
+	known := defangedAdminToken()
+	enc, err := oracle.Encrypt(known)
+	if err != nil {
+		return nil, err
+	}
+	return CTRBitFlip(enc, len(commentPrefix), known, adminToken)
+}
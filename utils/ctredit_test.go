@@ -0,0 +1,83 @@
+package utils
+
+import (
+	"encoding/base64"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEdit(t *testing.T) {
+	key := []byte("YELLOW SUBMARINE")
+	nonce := []byte("12345678")
+	a, err := NewAES(key, AESCTR, WithNonce(nonce))
+	require.NoError(t, err)
+	enc, err := a.Encrypt([]byte("the quick brown fox jumps over the lazy dog"))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		offset  int
+		newtext string
+		want    string
+	}{
+		{name: "middle", offset: 16, newtext: "cat", want: "the quick brown cat jumps over the lazy dog"},
+		{name: "start", offset: 0, newtext: "THE", want: "THE quick brown fox jumps over the lazy dog"},
+		{name: "grow", offset: 40, newtext: "dogs!", want: "the quick brown fox jumps over the lazy dogs!"},
+		{name: "append", offset: 43, newtext: ".", want: "the quick brown fox jumps over the lazy dog."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			edited, err := Edit(enc, key, tt.offset, []byte(tt.newtext), WithNonce(nonce))
+			require.NoError(t, err)
+			txt, err := a.Decrypt(edited)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, string(txt))
+		})
+	}
+
+	t.Run("bad offset", func(t *testing.T) {
+		_, err := Edit(enc, key, len(enc)+1, []byte("x"), WithNonce(nonce))
+		require.Error(t, err)
+		_, err = Edit(enc, key, -1, []byte("x"), WithNonce(nonce))
+		require.Error(t, err)
+	})
+}
+
+// set 4 challenge 25
+func TestRecoverCTRWithEdit(t *testing.T) {
+	b64, err := os.ReadFile("testdata/7.txt")
+	require.NoError(t, err)
+	enc, err := base64.StdEncoding.DecodeString(string(b64))
+	require.NoError(t, err)
+	ecb, err := NewAES([]byte("YELLOW SUBMARINE"), AESECB)
+	require.NoError(t, err)
+	want, err := ecb.Decrypt(enc)
+	require.NoError(t, err)
+
+	oracle, err := NewCTREditOracle()
+	require.NoError(t, err)
+	ct, err := oracle.Encrypt(want)
+	require.NoError(t, err)
+
+	got, err := RecoverCTRWithEdit(ct, oracle.Edit)
+	require.NoError(t, err)
+	assert.Equal(t, string(want), string(got))
+}
+
+// set 4 challenge 26
+func TestForgeCTRAdmin(t *testing.T) {
+	oracle, err := NewCommentOracle(AESCTR)
+	require.NoError(t, err)
+
+	forged, err := ForgeCTRAdmin(oracle)
+	require.NoError(t, err)
+	admin, err := oracle.IsAdmin(forged)
+	require.NoError(t, err)
+	assert.True(t, admin)
+
+	_, err = CTRBitFlip(forged, len(forged)-1, []byte("ab"), []byte("cd"))
+	require.Error(t, err)
+}
@@ -0,0 +1,156 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+// Encrypter is anything that encrypts attacker controlled input, such as AESECBOracle
+type Encrypter interface {
+	Encrypt([]byte) ([]byte, error)
+}
+
+var ErrNotECB = errors.New("oracle is not using ECB")
+
+// ECBSuffixResult is the output of the byte-at-a-time ECB attack
+type ECBSuffixResult struct {
+	BlockSize int
+	// PrefixLen is the length of any hidden bytes the oracle prepends to our input
+	PrefixLen int
+	// Suffix is the recovered hidden data the oracle appends to our input
+	Suffix []byte
+}
+
+// BreakECBSuffix recovers the hidden suffix of an oracle that ECB encrypts
+// prefix || input || suffix, one byte at a time. the block size, mode and
+// prefix length are all detected from the oracle
+func BreakECBSuffix(oracle Encrypter) (*ECBSuffixResult, error) {
+	blockSize, hiddenLen, err := detectBlockSize(oracle)
+	if err != nil {
+		return nil, err
+	}
+
+	// identical input blocks must encrypt to identical output blocks
+	enc, err := oracle.Encrypt(bytes.Repeat([]byte{'A'}, 3*blockSize))
+	if err != nil {
+		return nil, err
+	}
+	if score, _ := DetectAES128ECB(enc, blockSize); score == 0 {
+		return nil, ErrNotECB
+	}
+
+	// alignPad fills out the last block of the prefix so that our input
+	// starts at block index `skip`
+	prefixLen, alignPad, err := DetectECBPrefix(oracle, blockSize)
+	if err != nil {
+		return nil, err
+	}
+	skip := (prefixLen + alignPad) / blockSize
+	suffixLen := hiddenLen - prefixLen
+
+	// known is filler followed by the recovered suffix. the last blockSize-1
+	// bytes are always the bytes preceding the byte under attack
+	known := bytes.Repeat([]byte{'A'}, blockSize-1)
+	for i := 0; i < suffixLen; i++ {
+		solutions, err := ecbDictionary(oracle, alignPad, known[len(known)-(blockSize-1):], skip, blockSize)
+		if err != nil {
+			return nil, err
+		}
+
+		// push the ith suffix byte to the end of a block
+		padLen := (blockSize - 1) - (i % blockSize)
+		input := make([]byte, alignPad+padLen)
+		copy(input[alignPad:], known[:padLen])
+		enc, err := oracle.Encrypt(input)
+		if err != nil {
+			return nil, err
+		}
+		block := skip + i/blockSize
+		b, exists := solutions[string(enc[block*blockSize:(block+1)*blockSize])]
+		if !exists {
+			return nil, fmt.Errorf("suffix byte %d not in solution map", i)
+		}
+		known = append(known, b)
+	}
+
+	return &ECBSuffixResult{
+		BlockSize: blockSize,
+		PrefixLen: prefixLen,
+		Suffix:    known[blockSize-1:],
+	}, nil
+}
+
+// detectBlockSize grows the input until the ciphertext grows. the jump is the block size
+// and the input length at the jump tells us how many hidden bytes the oracle adds
+func detectBlockSize(oracle Encrypter) (blockSize, hiddenLen int, err error) {
+	enc, err := oracle.Encrypt(nil)
+	if err != nil {
+		return 0, 0, err
+	}
+	initial := len(enc)
+	for n := 1; n <= 256; n++ {
+		enc, err := oracle.Encrypt(bytes.Repeat([]byte{'A'}, n))
+		if err != nil {
+			return 0, 0, err
+		}
+		if len(enc) > initial {
+			// hidden + n fills a whole number of blocks and pkcs7 adds one more
+			return len(enc) - initial, initial - n, nil
+		}
+	}
+	return 0, 0, fmt.Errorf("ciphertext length did not change, cannot detect block size")
+}
+
+// DetectECBPrefix measures the hidden prefix an ECB oracle puts in front of
+// our input. it sends pad bytes followed by two blocks of one filler byte,
+// growing the pad until the filler lands on a block boundary and encrypts to
+// two identical blocks. alignPad is the pad that took
+//
+// a prefix with repeated blocks of its own, or one ending in the filler
+// byte, could fake the match, so the same input is sent with two different
+// fillers: our blocks are the pair at the same index in both ciphertexts
+// that changed with the filler
+func DetectECBPrefix(oracle Encrypter, blockSize int) (prefixLen, alignPad int, err error) {
+	if blockSize <= 0 {
+		return 0, 0, fmt.Errorf("bad block size %d", blockSize)
+	}
+	for extra := 0; extra < blockSize; extra++ {
+		var encs [2][][]byte
+		for j, filler := range []byte{'A', 'B'} {
+			input := bytes.Repeat([]byte{'P'}, extra)
+			input = append(input, bytes.Repeat([]byte{filler}, 2*blockSize)...)
+			enc, err := oracle.Encrypt(input)
+			if err != nil {
+				return 0, 0, err
+			}
+			encs[j] = chunk(enc, blockSize)
+		}
+
+		a, b := encs[0], encs[1]
+		for i := 0; i+1 < len(a) && i+1 < len(b); i++ {
+			if bytes.Equal(a[i], a[i+1]) && bytes.Equal(b[i], b[i+1]) && !bytes.Equal(a[i], b[i]) {
+				return i*blockSize - extra, extra, nil
+			}
+		}
+	}
+	return 0, 0, fmt.Errorf("could not align input to a block boundary")
+}
+
+// ecbDictionary maps the encrypted block of alignPad || known || b to b for every byte b
+func ecbDictionary(oracle Encrypter, alignPad int, known []byte, block, blockSize int) (map[string]byte, error) {
+	input := make([]byte, alignPad+len(known)+1)
+	copy(input[alignPad:], known)
+	attackPos := len(input) - 1
+
+	solutions := make(map[string]byte)
+	for i := 0; i < 256; i++ {
+		input[attackPos] = byte(i)
+		enc, err := oracle.Encrypt(input)
+		if err != nil {
+			return nil, err
+		}
+		solutions[string(enc[block*blockSize:(block+1)*blockSize])] = byte(i)
+	}
+	return solutions, nil
+}
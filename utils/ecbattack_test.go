@@ -0,0 +1,88 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// xorBlock is an insecure block cipher of any width: xor with the key
+type xorBlock struct {
+	key []byte
+}
+
+func (c *xorBlock) BlockSize() int { return len(c.key) }
+
+func (c *xorBlock) Encrypt(dst, src []byte) {
+	for i := range c.key {
+		dst[i] = src[i] ^ c.key[i]
+	}
+}
+
+func (c *xorBlock) Decrypt(dst, src []byte) { c.Encrypt(dst, src) }
+
+// prefixOracle ECB encrypts prefix || input || suffix
+type prefixOracle struct {
+	ecb            *BlockMode
+	prefix, suffix []byte
+}
+
+func (o *prefixOracle) Encrypt(txt []byte) ([]byte, error) {
+	d := append(append(append([]byte{}, o.prefix...), txt...), o.suffix...)
+	return o.ecb.Encrypt(d)
+}
+
+func TestDetectECBPrefix(t *testing.T) {
+	t.Run("aes oracle", func(t *testing.T) {
+		for i := 0; i < 10; i++ {
+			oracle, err := NewAESECBOracle([]byte("hidden suffix"), true)
+			require.NoError(t, err)
+			prefixLen, alignPad, err := DetectECBPrefix(oracle, oracle.BlockSize())
+			require.NoError(t, err)
+			assert.Equal(t, len(oracle.prefix), prefixLen)
+			assert.Zero(t, (prefixLen+alignPad)%oracle.BlockSize())
+		}
+	})
+
+	// blocks of the prefix that repeat, or a prefix ending in the filler
+	// byte, must not be mistaken for our input
+	prefixes := map[string][]byte{
+		"empty":          nil,
+		"one byte":       []byte("x"),
+		"ends in filler": append(bytes.Repeat([]byte{'z'}, 37), bytes.Repeat([]byte{'A'}, 9)...),
+		"ends in pad":    append(bytes.Repeat([]byte{'z'}, 21), bytes.Repeat([]byte{'P'}, 5)...),
+		"repeated block": bytes.Repeat([]byte{'A'}, 70),
+		"mixed repeats":  append(bytes.Repeat([]byte{'Q'}, 64), 'A', 'A', 'A'),
+	}
+	for _, blockSize := range []int{16, 24, 32} {
+		key, err := randomBytes(blockSize)
+		require.NoError(t, err)
+		ecb, err := NewBlockMode(&xorBlock{key: key}, AESECB)
+		require.NoError(t, err)
+
+		for name, prefix := range prefixes {
+			t.Run(fmt.Sprintf("%d byte blocks %s", blockSize, name), func(t *testing.T) {
+				oracle := &prefixOracle{ecb: ecb, prefix: prefix, suffix: bytes.Repeat([]byte{'A'}, 2*blockSize)}
+				prefixLen, alignPad, err := DetectECBPrefix(oracle, blockSize)
+				require.NoError(t, err)
+				assert.Equal(t, len(prefix), prefixLen)
+				assert.Equal(t, (blockSize-len(prefix)%blockSize)%blockSize, alignPad)
+			})
+		}
+	}
+
+	t.Run("bad block size", func(t *testing.T) {
+		_, _, err := DetectECBPrefix(&prefixOracle{}, 0)
+		require.Error(t, err)
+	})
+
+	t.Run("not ecb", func(t *testing.T) {
+		a, err := NewAES([]byte("YELLOW SUBMARINE"), AESCBC)
+		require.NoError(t, err)
+		_, _, err = DetectECBPrefix(a, 16)
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,187 @@
+package utils
+
+import (
+	"errors"
+	"strings"
+)
+
+var ErrNoCiphertexts = errors.New("no ciphertexts")
+
+// FixedNonceResult is the keystream shared by CTR ciphertexts encrypted
+// under one key and nonce, and the plaintexts it decrypts them to
+type FixedNonceResult struct {
+	KeyStream []byte
+	// Plaintexts are the ciphertexts xored with as much keystream as was recovered
+	Plaintexts [][]byte
+}
+
+// BreakFixedNonceCTR treats ciphertexts that share a CTR nonce as repeating
+// key xor with a key as long as the shortest ciphertext: truncated and
+// concatenated, each column is single byte xor and is scored as English
+func BreakFixedNonceCTR(ciphertexts [][]byte) (*FixedNonceResult, error) {
+	if len(ciphertexts) == 0 {
+		return nil, ErrNoCiphertexts
+	}
+	minLen := len(ciphertexts[0])
+	for _, ct := range ciphertexts {
+		if len(ct) < minLen {
+			minLen = len(ct)
+		}
+	}
+	if minLen == 0 {
+		return &FixedNonceResult{Plaintexts: applyKeyStream(ciphertexts, nil)}, nil
+	}
+
+	data := make([]byte, 0, minLen*len(ciphertexts))
+	for _, ct := range ciphertexts {
+		data = append(data, ct[:minLen]...)
+	}
+
+	keyStream := make([]byte, minLen)
+	for i, column := range transpose(chunk(data, minLen)) {
+		var best float64
+		for k := 0; k < 256; k++ {
+			txt := XorCipher(column, byte(k))
+			score := SimpleEnglishScore(string(txt)) + caseBonus(txt, i == 0)
+			if score > best {
+				best, keyStream[i] = score, byte(k)
+			}
+		}
+	}
+	return &FixedNonceResult{
+		KeyStream:  keyStream,
+		Plaintexts: applyKeyStream(ciphertexts, keyStream),
+	}, nil
+}
+
+// ExtendFixedNonceCTR carries on from BreakFixedNonceCTR past the shortest
+// ciphertext. fewer ciphertexts cover each later column, too few for letter
+// frequencies, so each byte is also scored on the bigrams and trigrams it
+// forms with the plaintext already recovered before it
+func ExtendFixedNonceCTR(ciphertexts [][]byte) (*FixedNonceResult, error) {
+	res, err := BreakFixedNonceCTR(ciphertexts)
+	if err != nil {
+		return nil, err
+	}
+	maxLen := 0
+	for _, ct := range ciphertexts {
+		if len(ct) > maxLen {
+			maxLen = len(ct)
+		}
+	}
+
+	keyStream := res.KeyStream
+	for i := len(keyStream); i < maxLen; i++ {
+		var (
+			best    float64
+			bestKey byte
+			first   = true
+		)
+		for k := 0; k < 256; k++ {
+			score := 0.0
+			for _, ct := range ciphertexts {
+				if len(ct) <= i {
+					continue
+				}
+				score += ngramScore(ct[:i], keyStream, ct[i]^byte(k))
+			}
+			if first || score > best {
+				best, bestKey, first = score, byte(k), false
+			}
+		}
+		keyStream = append(keyStream, bestKey)
+	}
+	res.KeyStream = keyStream
+	res.Plaintexts = applyKeyStream(ciphertexts, keyStream)
+	return res, nil
+}
+
+// caseBonus breaks the tie between k and k^0x20, which SimpleEnglishScore
+// scores the same: lines start with capitals, and mostly continue in lowercase
+func caseBonus(txt []byte, lineStart bool) float64 {
+	n := 0
+	for _, c := range txt {
+		if (lineStart && c >= 'A' && c <= 'Z') || (!lineStart && c >= 'a' && c <= 'z') {
+			n++
+		}
+	}
+	return float64(n) / float64(len(txt))
+}
+
+// applyKeyStream decrypts as much of each ciphertext as keyStream covers
+func applyKeyStream(ciphertexts [][]byte, keyStream []byte) [][]byte {
+	out := make([][]byte, len(ciphertexts))
+	for i, ct := range ciphertexts {
+		n := len(ct)
+		if n > len(keyStream) {
+			n = len(keyStream)
+		}
+		out[i], _ = FixedXor(ct[:n], keyStream[:n])
+	}
+	return out
+}
+
+var (
+	// most frequent English bigrams and trigrams, per cent of all bigrams and trigrams
+	// http://practicalcryptography.com/cryptanalysis/letter-frequencies-various-languages/english-letter-frequencies/
+	bigrams = map[string]float64{
+		"th": 3.56, "he": 3.07, "in": 2.43, "er": 2.05, "an": 1.99, "re": 1.85,
+		"on": 1.76, "at": 1.49, "en": 1.45, "nd": 1.35, "ti": 1.34, "es": 1.34,
+		"or": 1.28, "te": 1.20, "of": 1.17, "ed": 1.17, "is": 1.13, "it": 1.12,
+		"al": 1.09, "ar": 1.07, "st": 1.05, "to": 1.04, "nt": 1.04, "ng": 0.95,
+		"se": 0.93, "ha": 0.93, "as": 0.87, "ou": 0.87, "io": 0.83, "le": 0.83,
+		"ve": 0.83, "co": 0.79, "me": 0.79, "de": 0.76, "hi": 0.76, "ri": 0.73,
+		"ro": 0.73, "ic": 0.70, "ne": 0.69, "ea": 0.69, "ra": 0.69, "ce": 0.65,
+	}
+	trigrams = map[string]float64{
+		"the": 1.81, "and": 0.73, "ing": 0.72, "ent": 0.42, "ion": 0.42,
+		"her": 0.36, "for": 0.34, "tha": 0.33, "nth": 0.33, "int": 0.32,
+		"ere": 0.31, "tio": 0.31, "ter": 0.30, "est": 0.28, "ers": 0.28,
+		"ati": 0.26, "hat": 0.26, "ate": 0.25, "all": 0.25, "eth": 0.24,
+		"hes": 0.24, "ver": 0.24, "his": 0.24, "oft": 0.22, "ith": 0.21,
+		"fth": 0.21, "sth": 0.21, "oth": 0.21, "res": 0.21, "ont": 0.20,
+	}
+)
+
+// ngramScore scores c as the plaintext byte following ct decrypted under
+// keyStream. unprintable bytes score far below anything printable
+func ngramScore(ct, keyStream []byte, c byte) float64 {
+	if c < 0x20 || c > 0x7e {
+		return -100
+	}
+	score := SimpleEnglishScore(string(c)) / 10
+	// digits and symbols are rare in prose
+	switch c {
+	case '.', ',', ';', ':', '?', '!', '\'', '-', '"':
+	default:
+		if !isLetter(c) && c != ' ' {
+			score -= 5
+		}
+	}
+
+	n := len(ct)
+	var prev []byte
+	for j := n - 2; j < n; j++ {
+		if j >= 0 {
+			prev = append(prev, ct[j]^keyStream[j])
+		}
+	}
+	if len(prev) > 0 {
+		p := prev[len(prev)-1]
+		if p == ' ' && c == ' ' {
+			score -= 5
+		}
+		if isLetter(p) && isLetter(c) {
+			score += 1
+		}
+		score += bigrams[strings.ToLower(string([]byte{p, c}))]
+	}
+	if len(prev) == 2 {
+		score += 2 * trigrams[strings.ToLower(string([]byte{prev[0], prev[1], c}))]
+	}
+	return score
+}
+
+func isLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
@@ -0,0 +1,80 @@
+package utils
+
+import (
+	"bufio"
+	"encoding/base64"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fixedNonceCiphertexts encrypts each line of a base64 file under one
+// random key with a zero nonce
+func fixedNonceCiphertexts(t *testing.T, file string) (plaintexts, ciphertexts [][]byte) {
+	t.Helper()
+	f, err := os.Open(file)
+	require.NoError(t, err)
+	defer f.Close()
+
+	key, err := randomBytes(16)
+	require.NoError(t, err)
+	a, err := NewAES(key, AESCTR)
+	require.NoError(t, err)
+
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		txt, err := base64.StdEncoding.DecodeString(s.Text())
+		require.NoError(t, err)
+		enc, err := a.Encrypt(txt)
+		require.NoError(t, err)
+		plaintexts = append(plaintexts, txt)
+		ciphertexts = append(ciphertexts, enc)
+	}
+	require.NoError(t, s.Err())
+	return plaintexts, ciphertexts
+}
+
+// accuracy is the fraction of recovered bytes that are right
+func accuracy(want, got [][]byte) float64 {
+	var total, right int
+	for i := range got {
+		for j := range got[i] {
+			total++
+			if got[i][j] == want[i][j] {
+				right++
+			}
+		}
+	}
+	return float64(right) / float64(total)
+}
+
+// set 3 challenges 19 and 20
+func TestBreakFixedNonceCTR(t *testing.T) {
+	want, cts := fixedNonceCiphertexts(t, "testdata/19.txt")
+
+	res, err := BreakFixedNonceCTR(cts)
+	require.NoError(t, err)
+	assert.Len(t, res.KeyStream, 20)
+	acc := accuracy(want, res.Plaintexts)
+	t.Logf("truncated accuracy %.3f", acc)
+	assert.Greater(t, acc, 0.9)
+
+	t.Run("extended", func(t *testing.T) {
+		res, err := ExtendFixedNonceCTR(cts)
+		require.NoError(t, err)
+		assert.Len(t, res.KeyStream, 38)
+		for i := range res.Plaintexts {
+			assert.Len(t, res.Plaintexts[i], len(want[i]))
+		}
+		acc := accuracy(want, res.Plaintexts)
+		t.Logf("extended accuracy %.3f", acc)
+		assert.Greater(t, acc, 0.9)
+	})
+
+	t.Run("no ciphertexts", func(t *testing.T) {
+		_, err := BreakFixedNonceCTR(nil)
+		require.ErrorIs(t, err, ErrNoCiphertexts)
+	})
+}
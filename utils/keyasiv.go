@@ -0,0 +1,82 @@
+package utils
+
+import (
+	"crypto/aes"
+	"errors"
+	"fmt"
+)
+
+// HighASCIIError is what a KeyAsIVOracle complains with. it helpfully
+// includes the offending plaintext
+type HighASCIIError struct {
+	Plaintext []byte
+}
+
+func (e *HighASCIIError) Error() string {
+	return fmt.Sprintf("invalid plaintext %q", e.Plaintext)
+}
+
+// KeyAsIVOracle encrypts with AES-CBC using the key as the iv. it bypasses
+// NewAES, which refuses to do this
+type KeyAsIVOracle struct {
+	key []byte
+	cbc *BlockMode
+}
+
+func NewKeyAsIVOracle() (*KeyAsIVOracle, error) {
+	key, err := randomBytes(16)
+	if err != nil {
+		return nil, err
+	}
+	c, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	m, err := NewBlockMode(c, AESCBC, WithIV(key))
+	if err != nil {
+		return nil, err
+	}
+	return &KeyAsIVOracle{key: key, cbc: m}, nil
+}
+
+func (o *KeyAsIVOracle) Encrypt(txt []byte) ([]byte, error) {
+	return o.cbc.Encrypt(txt)
+}
+
+// Decrypt returns a HighASCIIError if the plaintext has any byte >= 0x80
+func (o *KeyAsIVOracle) Decrypt(ciphertext []byte) error {
+	txt, err := o.cbc.Decrypt(ciphertext)
+	if err != nil {
+		return err
+	}
+	for _, b := range txt {
+		if b >= 0x80 {
+			return &HighASCIIError{Plaintext: txt}
+		}
+	}
+	return nil
+}
+
+// RecoverKeyAsIV recovers the key of a CBC oracle using iv == key from a
+// ciphertext of at least two blocks. it submits C1 || 0 || C1 || C2..Cn: the
+// third block decrypts to D(C1) and the first to D(C1) ^ iv, so
+// P'1 ^ P'3 = iv = key. the trailing blocks keep the padding valid
+func RecoverKeyAsIV(ciphertext []byte, decrypt func([]byte) error) ([]byte, error) {
+	const blockSize = aes.BlockSize
+	if len(ciphertext) < 2*blockSize || len(ciphertext)%blockSize != 0 {
+		return nil, fmt.Errorf("need at least two whole blocks, got %d bytes", len(ciphertext))
+	}
+	c1 := ciphertext[:blockSize]
+	forged := make([]byte, 0, len(ciphertext)+2*blockSize)
+	forged = append(forged, c1...)
+	forged = append(forged, make([]byte, blockSize)...)
+	forged = append(forged, c1...)
+	forged = append(forged, ciphertext[blockSize:]...)
+
+	var highASCII *HighASCIIError
+	if err := decrypt(forged); !errors.As(err, &highASCII) {
+		return nil, fmt.Errorf("oracle did not leak the plaintext: %v", err)
+	}
+	p := highASCII.Plaintext
+	return FixedXor(p[:blockSize], p[2*blockSize:3*blockSize])
+}
@@ -0,0 +1,69 @@
+package utils
+
+import (
+	"crypto/aes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// set 4 challenge 27
+func TestRecoverKeyAsIV(t *testing.T) {
+	oracle, err := NewKeyAsIVOracle()
+	require.NoError(t, err)
+
+	msg := []byte("comment1=cooking%20MCs;userdata=hello;comment2=%20like%20a%20pound%20of%20bacon")
+	enc, err := oracle.Encrypt(msg)
+	require.NoError(t, err)
+	require.NoError(t, oracle.Decrypt(enc))
+
+	key, err := RecoverKeyAsIV(enc, oracle.Decrypt)
+	require.NoError(t, err)
+	assert.Equal(t, oracle.key, key)
+
+	// the key decrypts anything the oracle produces
+	c, err := aes.NewCipher(key)
+	require.NoError(t, err)
+	m, err := NewBlockMode(c, AESCBC, WithIV(key))
+	require.NoError(t, err)
+	txt, err := m.Decrypt(enc)
+	require.NoError(t, err)
+	assert.Equal(t, msg, txt)
+
+	t.Run("short ciphertext", func(t *testing.T) {
+		_, err := RecoverKeyAsIV(enc[:16], oracle.Decrypt)
+		require.Error(t, err)
+	})
+
+	t.Run("no leak", func(t *testing.T) {
+		_, err := RecoverKeyAsIV(enc, func([]byte) error { return nil })
+		require.Error(t, err)
+	})
+}
+
+func TestNewAES_KeyAsIV(t *testing.T) {
+	key := []byte("YELLOW SUBMARINE")
+	_, err := NewAES(key, AESCBC, WithIV(key))
+	require.ErrorIs(t, err, ErrKeyAsIV)
+
+	_, err = NewAES(key, AESCBC, WithIV([]byte("0123456789abcdef")))
+	require.NoError(t, err)
+	// ctr does not use the iv
+	_, err = NewAES(key, AESCTR)
+	require.NoError(t, err)
+
+	t.Run("zero key with default iv", func(t *testing.T) {
+		zero := make([]byte, 16)
+		a, err := NewAES(zero, AESCBC)
+		require.NoError(t, err)
+		enc, err := a.Encrypt([]byte("hello"))
+		require.NoError(t, err)
+		txt, err := a.Decrypt(enc)
+		require.NoError(t, err)
+		assert.Equal(t, "hello", string(txt))
+
+		_, err = NewAES(zero, AESCBC, WithIV(make([]byte, 16)))
+		require.ErrorIs(t, err, ErrKeyAsIV)
+	})
+}
@@ -0,0 +1,91 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"hash"
+
+	"github.com/krehermann/go-cryptopals/md4"
+	"github.com/krehermann/go-cryptopals/sha1"
+)
+
+// Extender describes a Merkle-Damgård hash well enough to resume it from a
+// published digest
+type Extender struct {
+	// GluePadding is the padding the hash appends to a message of msgLen bytes
+	GluePadding func(msgLen uint64) []byte
+	// Resume continues hashing from digest as if processedLen bytes had been consumed
+	Resume func(digest []byte, processedLen uint64) (hash.Hash, error)
+}
+
+var SHA1Extender = Extender{
+	GluePadding: sha1.GluePadding,
+	Resume: func(digest []byte, processedLen uint64) (hash.Hash, error) {
+		if len(digest) != sha1.Size {
+			return nil, fmt.Errorf("sha1 digest must be %d bytes, got %d", sha1.Size, len(digest))
+		}
+		var sum [sha1.Size]byte
+		copy(sum[:], digest)
+		return sha1.NewFromState(sha1.StateFromSum(sum), processedLen), nil
+	},
+}
+
+var MD4Extender = Extender{
+	GluePadding: md4.GluePadding,
+	Resume: func(digest []byte, processedLen uint64) (hash.Hash, error) {
+		if len(digest) != md4.Size {
+			return nil, fmt.Errorf("md4 digest must be %d bytes, got %d", md4.Size, len(digest))
+		}
+		var sum [md4.Size]byte
+		copy(sum[:], digest)
+		return md4.NewFromState(md4.StateFromSum(sum), processedLen), nil
+	},
+}
+
+var ErrForgeryRejected = errors.New("no forgery accepted")
+
+// Forgery is a message and a valid MAC for it that the key holder never produced
+type Forgery struct {
+	// Msg is msg || glue padding || extension, without the key
+	Msg    []byte
+	MAC    []byte
+	KeyLen int
+}
+
+// Extend forges the MAC of key || msg || glue || extension from the MAC of
+// key || msg, assuming the key is keyLen bytes
+func (e Extender) Extend(mac, msg, extension []byte, keyLen int) (*Forgery, error) {
+	origLen := uint64(keyLen + len(msg))
+	glue := e.GluePadding(origLen)
+
+	h, err := e.Resume(mac, origLen+uint64(len(glue)))
+	if err != nil {
+		return nil, err
+	}
+	h.Write(extension)
+
+	forged := make([]byte, 0, len(msg)+len(glue)+len(extension))
+	forged = append(forged, msg...)
+	forged = append(forged, glue...)
+	forged = append(forged, extension...)
+	return &Forgery{
+		Msg:    forged,
+		MAC:    h.Sum(nil),
+		KeyLen: keyLen,
+	}, nil
+}
+
+// ForgeMAC tries every key length in [minKeyLen, maxKeyLen] and returns the
+// first forgery verify accepts
+func (e Extender) ForgeMAC(mac, msg, extension []byte, minKeyLen, maxKeyLen int, verify func(msg, mac []byte) bool) (*Forgery, error) {
+	for keyLen := minKeyLen; keyLen <= maxKeyLen; keyLen++ {
+		f, err := e.Extend(mac, msg, extension, keyLen)
+		if err != nil {
+			return nil, err
+		}
+		if verify(f.Msg, f.MAC) {
+			return f, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: key length not in [%d, %d]", ErrForgeryRejected, minKeyLen, maxKeyLen)
+}
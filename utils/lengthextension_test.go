@@ -0,0 +1,77 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/rand"
+	"hash"
+	"math/big"
+	"testing"
+
+	"github.com/krehermann/go-cryptopals/md4"
+	"github.com/krehermann/go-cryptopals/sha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// secretPrefixMAC is MAC(key || msg), the construction length extension breaks
+type secretPrefixMAC struct {
+	key     []byte
+	newHash func() hash.Hash
+}
+
+func newSecretPrefixMAC(t *testing.T, newHash func() hash.Hash) *secretPrefixMAC {
+	n, err := rand.Int(rand.Reader, big.NewInt(32))
+	require.NoError(t, err)
+	key, err := randomBytes(int(n.Int64()) + 1)
+	require.NoError(t, err)
+	return &secretPrefixMAC{key: key, newHash: newHash}
+}
+
+func (m *secretPrefixMAC) Sign(msg []byte) []byte {
+	h := m.newHash()
+	h.Write(m.key)
+	h.Write(msg)
+	return h.Sum(nil)
+}
+
+func (m *secretPrefixMAC) Verify(msg, mac []byte) bool {
+	return bytes.Equal(m.Sign(msg), mac)
+}
+
+// set 4 challenge 29
+func TestSHA1LengthExtension(t *testing.T) {
+	oracle := newSecretPrefixMAC(t, func() hash.Hash { return sha1.New() })
+
+	msg := []byte("comment1=cooking%20MCs;userdata=foo;comment2=%20like%20a%20pound%20of%20bacon")
+	ext := []byte(";admin=true")
+	mac := oracle.Sign(msg)
+
+	f, err := SHA1Extender.ForgeMAC(mac, msg, ext, 0, 64, oracle.Verify)
+	require.NoError(t, err)
+	assert.Equal(t, len(oracle.key), f.KeyLen)
+	assert.True(t, bytes.HasPrefix(f.Msg, msg))
+	assert.True(t, bytes.HasSuffix(f.Msg, ext))
+	assert.True(t, oracle.Verify(f.Msg, f.MAC))
+
+	_, err = SHA1Extender.ForgeMAC(mac, msg, ext, 40, 64, oracle.Verify)
+	require.ErrorIs(t, err, ErrForgeryRejected)
+}
+
+// set 4 challenge 30
+func TestMD4LengthExtension(t *testing.T) {
+	oracle := newSecretPrefixMAC(t, func() hash.Hash { return md4.New() })
+
+	msg := []byte("comment1=cooking%20MCs;userdata=foo;comment2=%20like%20a%20pound%20of%20bacon")
+	ext := []byte(";admin=true")
+	mac := oracle.Sign(msg)
+
+	f, err := MD4Extender.ForgeMAC(mac, msg, ext, 0, 64, oracle.Verify)
+	require.NoError(t, err)
+	assert.Equal(t, len(oracle.key), f.KeyLen)
+	assert.True(t, bytes.HasSuffix(f.Msg, ext))
+	assert.True(t, oracle.Verify(f.Msg, f.MAC))
+
+	// a sha1 digest is the wrong size to resume md4
+	_, err = MD4Extender.Extend(make([]byte, 20), msg, ext, 16)
+	require.Error(t, err)
+}
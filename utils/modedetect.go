@@ -0,0 +1,91 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// ModeGuess is the output of ClassifyMode
+type ModeGuess struct {
+	Mode AESMode
+	// Confidence is in [0, 1]
+	Confidence float64
+}
+
+// classifyBlocks is the length of the chosen plaintext, in blocks. whatever
+// the oracle adds in front, all but one of them land on block boundaries
+const classifyBlocks = 8
+
+// ClassifyMode tells ECB from CBC with a single chosen plaintext of
+// identical blocks: ECB encrypts them to identical ciphertext blocks, CBC
+// does not. it measures the share of the classifyBlocks-1 aligned input
+// blocks that show up repeated in the ciphertext. ECB is guessed when at
+// least half do, with that share as the confidence; otherwise CBC, with
+// confidence the share that did not repeat. a CBC block pair collides by
+// chance with probability 2^-128, so for real AES the share is 0 or 1
+func ClassifyMode(oracle Encrypter, blockSize int) (*ModeGuess, error) {
+	if blockSize <= 0 {
+		return nil, fmt.Errorf("bad block size %d", blockSize)
+	}
+	enc, err := oracle.Encrypt(bytes.Repeat([]byte{'A'}, classifyBlocks*blockSize))
+	if err != nil {
+		return nil, err
+	}
+	// score is the number of ciphertext blocks equal to some other block
+	score, _ := DetectAES128ECB(enc, blockSize)
+	share := score / (classifyBlocks - 1)
+	if share > 1 {
+		// the input was aligned, so all classifyBlocks repeated
+		share = 1
+	}
+	if share >= 0.5 {
+		return &ModeGuess{Mode: AESECB, Confidence: share}, nil
+	}
+	return &ModeGuess{Mode: AESCBC, Confidence: 1 - share}, nil
+}
+
+// ModeOracle is an Encrypter that can report the mode it last used, like AESOracle
+type ModeOracle interface {
+	Encrypter
+	Mode() AESMode
+}
+
+// ModeTrialReport summarizes RunModeTrials
+type ModeTrialReport struct {
+	Trials  int
+	Correct int
+	// ByMode is the number of trials in which the oracle used each mode
+	ByMode         map[AESMode]int
+	MeanConfidence float64
+}
+
+func (r *ModeTrialReport) Accuracy() float64 {
+	if r.Trials == 0 {
+		return 0
+	}
+	return float64(r.Correct) / float64(r.Trials)
+}
+
+// RunModeTrials classifies n encryptions by oracle and checks each guess
+// against the mode the oracle reports
+func RunModeTrials(oracle ModeOracle, blockSize, n int) (*ModeTrialReport, error) {
+	r := &ModeTrialReport{ByMode: make(map[AESMode]int)}
+	var totalConfidence float64
+	for i := 0; i < n; i++ {
+		guess, err := ClassifyMode(oracle, blockSize)
+		if err != nil {
+			return nil, err
+		}
+		actual := oracle.Mode()
+		r.Trials++
+		r.ByMode[actual]++
+		if guess.Mode == actual {
+			r.Correct++
+		}
+		totalConfidence += guess.Confidence
+	}
+	if r.Trials > 0 {
+		r.MeanConfidence = totalConfidence / float64(r.Trials)
+	}
+	return r, nil
+}
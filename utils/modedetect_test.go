@@ -0,0 +1,85 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// partialECB is ECB that only repeats the first keep blocks: every later
+// block is xored with its index before encryption
+type partialECB struct {
+	ecb  *AES
+	keep int
+}
+
+func (o *partialECB) Encrypt(txt []byte) ([]byte, error) {
+	d := append([]byte{}, txt...)
+	for i := o.keep; i < len(d)/16; i++ {
+		d[i*16] ^= byte(i)
+	}
+	return o.ecb.Encrypt(d)
+}
+
+func TestClassifyMode(t *testing.T) {
+	key := []byte("YELLOW SUBMARINE")
+	tests := []struct {
+		mode AESMode
+	}{
+		{mode: AESECB},
+		{mode: AESCBC},
+	}
+	for _, tt := range tests {
+		a, err := NewAES(key, tt.mode)
+		require.NoError(t, err)
+		guess, err := ClassifyMode(a, 16)
+		require.NoError(t, err)
+		assert.Equal(t, tt.mode, guess.Mode)
+		assert.Equal(t, 1.0, guess.Confidence)
+	}
+
+	t.Run("partial repeats", func(t *testing.T) {
+		ecb, err := NewAES(key, AESECB)
+		require.NoError(t, err)
+		tests := []struct {
+			keep       int
+			want       AESMode
+			confidence float64
+		}{
+			{keep: 8, want: AESECB, confidence: 1},
+			{keep: 6, want: AESECB, confidence: 6.0 / 7},
+			{keep: 4, want: AESECB, confidence: 4.0 / 7},
+			{keep: 3, want: AESCBC, confidence: 4.0 / 7},
+			{keep: 2, want: AESCBC, confidence: 5.0 / 7},
+			{keep: 0, want: AESCBC, confidence: 1},
+		}
+		for _, tt := range tests {
+			guess, err := ClassifyMode(&partialECB{ecb: ecb, keep: tt.keep}, 16)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, guess.Mode, "keep %d", tt.keep)
+			assert.InDelta(t, tt.confidence, guess.Confidence, 1e-9, "keep %d", tt.keep)
+		}
+	})
+
+	t.Run("bad block size", func(t *testing.T) {
+		_, err := ClassifyMode(&AESOracle{}, 0)
+		require.Error(t, err)
+	})
+}
+
+// set 2 challenge 11
+func TestRunModeTrials(t *testing.T) {
+	r, err := RunModeTrials(&AESOracle{}, 16, 200)
+	require.NoError(t, err)
+	assert.Equal(t, 200, r.Trials)
+	assert.Equal(t, 1.0, r.Accuracy())
+	// real AES leaves no middle ground
+	assert.InDelta(t, 1.0, r.MeanConfidence, 1e-9)
+	// both modes should come up in 200 coin flips
+	assert.NotZero(t, r.ByMode[AESECB])
+	assert.NotZero(t, r.ByMode[AESCBC])
+	t.Logf("%+v", r)
+
+	assert.Zero(t, (&ModeTrialReport{}).Accuracy())
+}
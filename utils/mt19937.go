@@ -0,0 +1,206 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// MT19937 is the 32 bit Mersenne Twister
+type MT19937 struct {
+	mt    [mtN]uint32
+	index int
+}
+
+const (
+	mtN         = 624
+	mtM         = 397
+	mtMatrixA   = 0x9908b0df
+	mtUpperMask = 0x80000000
+	mtLowerMask = 0x7fffffff
+	mtInitMult  = 1812433253
+)
+
+var ErrSeedNotFound = errors.New("seed not found")
+
+func NewMT19937(seed uint32) *MT19937 {
+	m := &MT19937{}
+	m.Seed(seed)
+	return m
+}
+
+func (m *MT19937) Seed(seed uint32) {
+	m.mt[0] = seed
+	for i := 1; i < mtN; i++ {
+		m.mt[i] = mtInitMult*(m.mt[i-1]^(m.mt[i-1]>>30)) + uint32(i)
+	}
+	m.index = mtN
+}
+
+func (m *MT19937) Uint32() uint32 {
+	if m.index >= mtN {
+		m.twist()
+	}
+	y := m.mt[m.index]
+	m.index++
+	return Temper(y)
+}
+
+func (m *MT19937) twist() {
+	for i := 0; i < mtN; i++ {
+		y := (m.mt[i] & mtUpperMask) | (m.mt[(i+1)%mtN] & mtLowerMask)
+		next := m.mt[(i+mtM)%mtN] ^ (y >> 1)
+		if y&1 != 0 {
+			next ^= mtMatrixA
+		}
+		m.mt[i] = next
+	}
+	m.index = 0
+}
+
+// Temper is the output transform applied to each state word
+func Temper(y uint32) uint32 {
+	y ^= y >> 11
+	y ^= (y << 7) & 0x9d2c5680
+	y ^= (y << 15) & 0xefc60000
+	y ^= y >> 18
+	return y
+}
+
+// Untemper inverts Temper, recovering the state word behind an output
+func Untemper(y uint32) uint32 {
+	y = undoRightShiftXor32(y, 18)
+	y = undoLeftShiftXorMask32(y, 15, 0xefc60000)
+	y = undoLeftShiftXorMask32(y, 7, 0x9d2c5680)
+	y = undoRightShiftXor32(y, 11)
+	return y
+}
+
+// undoRightShiftXor32 inverts y ^= y >> shift. the top shift bits are
+// unchanged, and each pass recovers shift more
+func undoRightShiftXor32(y uint32, shift uint) uint32 {
+	result := y
+	for i := uint(0); i < 32; i += shift {
+		result = y ^ (result >> shift)
+	}
+	return result
+}
+
+// undoLeftShiftXorMask32 inverts y ^= (y << shift) & mask
+func undoLeftShiftXorMask32(y uint32, shift uint, mask uint32) uint32 {
+	result := y
+	for i := uint(0); i < 32; i += shift {
+		result = y ^ ((result << shift) & mask)
+	}
+	return result
+}
+
+// CloneMT19937 rebuilds a generator from 624 consecutive outputs. the clone
+// produces the same values the original produces next
+func CloneMT19937(outputs []uint32) (*MT19937, error) {
+	if len(outputs) != mtN {
+		return nil, fmt.Errorf("need %d outputs to clone, got %d", mtN, len(outputs))
+	}
+	m := &MT19937{index: mtN}
+	for i, out := range outputs {
+		m.mt[i] = Untemper(out)
+	}
+	return m, nil
+}
+
+// RecoverTimeSeed brute forces a generator seeded with a unix timestamp in
+// [from, to] whose first output was first
+func RecoverTimeSeed(first uint32, from, to time.Time) (uint32, error) {
+	m := &MT19937{}
+	for ts := from.Unix(); ts <= to.Unix(); ts++ {
+		m.Seed(uint32(ts))
+		if m.Uint32() == first {
+			return uint32(ts), nil
+		}
+	}
+	return 0, fmt.Errorf("%w: no timestamp between %s and %s", ErrSeedNotFound, from, to)
+}
+
+// MT19937_64 is the 64 bit Mersenne Twister
+type MT19937_64 struct {
+	mt    [mt64N]uint64
+	index int
+}
+
+const (
+	mt64N         = 312
+	mt64M         = 156
+	mt64MatrixA   = 0xb5026f5aa96619e9
+	mt64UpperMask = 0xffffffff80000000
+	mt64LowerMask = 0x7fffffff
+	mt64InitMult  = 6364136223846793005
+)
+
+func NewMT19937_64(seed uint64) *MT19937_64 {
+	m := &MT19937_64{}
+	m.Seed(seed)
+	return m
+}
+
+func (m *MT19937_64) Seed(seed uint64) {
+	m.mt[0] = seed
+	for i := 1; i < mt64N; i++ {
+		m.mt[i] = mt64InitMult*(m.mt[i-1]^(m.mt[i-1]>>62)) + uint64(i)
+	}
+	m.index = mt64N
+}
+
+func (m *MT19937_64) Uint64() uint64 {
+	if m.index >= mt64N {
+		m.twist()
+	}
+	y := m.mt[m.index]
+	m.index++
+	return Temper64(y)
+}
+
+func (m *MT19937_64) twist() {
+	for i := 0; i < mt64N; i++ {
+		y := (m.mt[i] & mt64UpperMask) | (m.mt[(i+1)%mt64N] & mt64LowerMask)
+		next := m.mt[(i+mt64M)%mt64N] ^ (y >> 1)
+		if y&1 != 0 {
+			next ^= mt64MatrixA
+		}
+		m.mt[i] = next
+	}
+	m.index = 0
+}
+
+// Temper64 is the output transform of the 64 bit generator
+func Temper64(y uint64) uint64 {
+	y ^= (y >> 29) & 0x5555555555555555
+	y ^= (y << 17) & 0x71d67fffeda60000
+	y ^= (y << 37) & 0xfff7eee000000000
+	y ^= y >> 43
+	return y
+}
+
+// Untemper64 inverts Temper64
+func Untemper64(y uint64) uint64 {
+	y = undoRightShiftXorMask64(y, 43, 0xffffffffffffffff)
+	y = undoLeftShiftXorMask64(y, 37, 0xfff7eee000000000)
+	y = undoLeftShiftXorMask64(y, 17, 0x71d67fffeda60000)
+	y = undoRightShiftXorMask64(y, 29, 0x5555555555555555)
+	return y
+}
+
+func undoRightShiftXorMask64(y uint64, shift uint, mask uint64) uint64 {
+	result := y
+	for i := uint(0); i < 64; i += shift {
+		result = y ^ ((result >> shift) & mask)
+	}
+	return result
+}
+
+func undoLeftShiftXorMask64(y uint64, shift uint, mask uint64) uint64 {
+	result := y
+	for i := uint(0); i < 64; i += shift {
+		result = y ^ ((result << shift) & mask)
+	}
+	return result
+}
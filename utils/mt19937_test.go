@@ -0,0 +1,84 @@
+package utils
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMT19937(t *testing.T) {
+	// reference outputs for the default seed 5489
+	m := NewMT19937(5489)
+	want := []uint32{3499211612, 581869302, 3890346734, 3586334585, 545404204}
+	for i, w := range want {
+		assert.Equal(t, w, m.Uint32(), "output %d", i)
+	}
+
+	// the c++ standard requires the 10000th output of a default seeded mt19937
+	m = NewMT19937(5489)
+	var got uint32
+	for i := 0; i < 10000; i++ {
+		got = m.Uint32()
+	}
+	assert.Equal(t, uint32(4123659995), got)
+}
+
+func TestMT19937_64(t *testing.T) {
+	m := NewMT19937_64(5489)
+	assert.Equal(t, uint64(14514284786278117030), m.Uint64())
+
+	m = NewMT19937_64(5489)
+	var got uint64
+	for i := 0; i < 10000; i++ {
+		got = m.Uint64()
+	}
+	assert.Equal(t, uint64(9981545732273789042), got)
+}
+
+func TestUntemper(t *testing.T) {
+	for _, y := range []uint32{0, 1, 0xffffffff, 0x80000000, 0xdeadbeef, 123456789} {
+		assert.Equal(t, y, Untemper(Temper(y)))
+	}
+	for _, y := range []uint64{0, 1, 0xffffffffffffffff, 0x8000000000000000, 0xdeadbeefcafebabe} {
+		assert.Equal(t, y, Untemper64(Temper64(y)))
+	}
+}
+
+// set 3 challenge 23
+func TestCloneMT19937(t *testing.T) {
+	orig := NewMT19937(uint32(time.Now().Unix()))
+	outputs := make([]uint32, mtN)
+	for i := range outputs {
+		outputs[i] = orig.Uint32()
+	}
+
+	clone, err := CloneMT19937(outputs)
+	require.NoError(t, err)
+	for i := 0; i < 2*mtN; i++ {
+		require.Equal(t, orig.Uint32(), clone.Uint32(), "output %d", i)
+	}
+
+	_, err = CloneMT19937(outputs[1:])
+	require.Error(t, err)
+}
+
+// set 3 challenge 22
+func TestRecoverTimeSeed(t *testing.T) {
+	wait, err := rand.Int(rand.Reader, big.NewInt(1000))
+	require.NoError(t, err)
+
+	now := time.Now()
+	seedTime := now.Add(-time.Duration(40+wait.Int64()) * time.Second)
+	first := NewMT19937(uint32(seedTime.Unix())).Uint32()
+
+	got, err := RecoverTimeSeed(first, now.Add(-2000*time.Second), now)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(seedTime.Unix()), got)
+
+	_, err = RecoverTimeSeed(first, now.Add(-10*time.Second), now)
+	require.ErrorIs(t, err, ErrSeedNotFound)
+}
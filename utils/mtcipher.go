@@ -0,0 +1,85 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"time"
+)
+
+// MTCipher is a stream cipher whose keystream is the output of MT19937
+// seeded with a 16 bit key. it has the same shape as AES
+type MTCipher struct {
+	seed uint16
+}
+
+func NewMTCipher(seed uint16) *MTCipher {
+	return &MTCipher{seed: seed}
+}
+
+func (c *MTCipher) Encrypt(src []byte) ([]byte, error) {
+	return XorEncrypt(src, mtKeyStream(NewMT19937(uint32(c.seed)), len(src)))
+}
+
+func (c *MTCipher) Decrypt(src []byte) ([]byte, error) {
+	return c.Encrypt(src)
+}
+
+// mtKeyStream returns n bytes of output, each 32 bit output little endian
+func mtKeyStream(m *MT19937, n int) []byte {
+	out := make([]byte, 0, n+4)
+	buf := make([]byte, 4)
+	for len(out) < n {
+		binary.LittleEndian.PutUint32(buf, m.Uint32())
+		out = append(out, buf...)
+	}
+	return out[:n]
+}
+
+// RecoverMTCipherSeed brute forces the 16 bit seed of a ciphertext whose
+// plaintext is known to end with knownSuffix
+func RecoverMTCipherSeed(ciphertext, knownSuffix []byte) (uint16, error) {
+	if len(knownSuffix) == 0 {
+		// every seed matches an empty suffix
+		return 0, fmt.Errorf("known suffix is empty")
+	}
+	if len(knownSuffix) > len(ciphertext) {
+		return 0, fmt.Errorf("known suffix longer than ciphertext (%d > %d)", len(knownSuffix), len(ciphertext))
+	}
+	m := &MT19937{}
+	for seed := 0; seed <= 0xffff; seed++ {
+		m.Seed(uint32(seed))
+		ks := mtKeyStream(m, len(ciphertext))
+		txt, err := XorEncrypt(ciphertext, ks)
+		if err != nil {
+			return 0, err
+		}
+		if bytes.HasSuffix(txt, knownSuffix) {
+			return uint16(seed), nil
+		}
+	}
+	return 0, ErrSeedNotFound
+}
+
+// GenerateResetToken is how not to make a password reset token: n bytes
+// of MT19937 seeded with the current unix time
+func GenerateResetToken(now time.Time, n int) []byte {
+	return mtKeyStream(NewMT19937(uint32(now.Unix())), n)
+}
+
+// IsTimeSeededToken reports whether token is the start of the MT19937
+// keystream for any unix time seed in the window before now. an empty
+// token is the start of every keystream, so it is never reported
+func IsTimeSeededToken(token []byte, now time.Time, window time.Duration) bool {
+	if len(token) == 0 {
+		return false
+	}
+	m := &MT19937{}
+	for ts := now.Add(-window).Unix(); ts <= now.Unix(); ts++ {
+		m.Seed(uint32(ts))
+		if bytes.Equal(mtKeyStream(m, len(token)), token) {
+			return true
+		}
+	}
+	return false
+}
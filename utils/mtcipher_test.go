@@ -0,0 +1,63 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/rand"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMTCipher_RoundTrip(t *testing.T) {
+	c := NewMTCipher(0xbeef)
+	msg := []byte("not a multiple of four bytes")
+	enc, err := c.Encrypt(msg)
+	require.NoError(t, err)
+	require.Len(t, enc, len(msg))
+	assert.NotEqual(t, msg, enc)
+
+	got, err := c.Decrypt(enc)
+	require.NoError(t, err)
+	assert.Equal(t, msg, got)
+}
+
+// set 3 challenge 24
+func TestRecoverMTCipherSeed(t *testing.T) {
+	seed, err := rand.Int(rand.Reader, big.NewInt(0x10000))
+	require.NoError(t, err)
+	prefix, err := generateBytes(5, 20)
+	require.NoError(t, err)
+
+	known := bytes.Repeat([]byte{'A'}, 14)
+	c := NewMTCipher(uint16(seed.Int64()))
+	enc, err := c.Encrypt(append(prefix, known...))
+	require.NoError(t, err)
+
+	got, err := RecoverMTCipherSeed(enc, known)
+	require.NoError(t, err)
+	assert.Equal(t, uint16(seed.Int64()), got)
+
+	_, err = RecoverMTCipherSeed(enc, nil)
+	require.Error(t, err)
+}
+
+func TestIsTimeSeededToken(t *testing.T) {
+	now := time.Now()
+	window := 30 * time.Minute
+
+	token := GenerateResetToken(now.Add(-5*time.Minute), 16)
+	assert.True(t, IsTimeSeededToken(token, now, window))
+
+	stale := GenerateResetToken(now.Add(-time.Hour), 16)
+	assert.False(t, IsTimeSeededToken(stale, now, window))
+
+	secure, err := randomBytes(16)
+	require.NoError(t, err)
+	assert.False(t, IsTimeSeededToken(secure, now, window))
+
+	assert.False(t, IsTimeSeededToken(nil, now, window))
+	assert.False(t, IsTimeSeededToken([]byte{}, now, window))
+}
@@ -0,0 +1,61 @@
+package utils
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// set 3 challenge 17
+func TestPaddingOracleAttacker_Decrypt(t *testing.T) {
+	inputs := []string{
+		"MDAwMDAwTm93IHRoYXQgdGhlIHBhcnR5IGlzIGp1bXBpbmc=",
+		"MDAwMDAxV2l0aCB0aGUgYmFzcyBraWNrZWQgaW4gYW5kIHRoZSBWZWdhJ3MgYXJlIHB1bXBpbic=",
+		"MDAwMDAyUXVpY2sgdG8gdGhlIHBvaW50LCB0byB0aGUgcG9pbnQsIG5vIGZha2luZw==",
+		"MDAwMDAzQ29va2luZyBNQydzIGxpa2UgYSBwb3VuZCBvZiBiYWNvbg==",
+		"MDAwMDA0QnVybmluZyAnZW0sIGlmIHlvdSBhaW4ndCBxdWljayBhbmQgbmltYmxl",
+		"MDAwMDA1SSBnbyBjcmF6eSB3aGVuIEkgaGVhciBhIGN5bWJhbA==",
+		"MDAwMDA2QW5kIGEgaGlnaCBoYXQgd2l0aCBhIHNvdXBlZCB1cCB0ZW1wbw==",
+		"MDAwMDA3SSdtIG9uIGEgcm9sbCwgaXQncyB0aW1lIHRvIGdvIHNvbG8=",
+		"MDAwMDA4b2xsaW4nIGluIG15IGZpdmUgcG9pbnQgb2g=",
+		"MDAwMDA5aXRoIG15IHJhZy10b3AgZG93biBzbyBteSBoYWlyIGNhbiBibG93",
+	}
+
+	oracle, err := NewCBCPaddingOracle()
+	require.NoError(t, err)
+
+	for _, in := range inputs {
+		want, err := base64.StdEncoding.DecodeString(in)
+		require.NoError(t, err)
+
+		iv, enc, err := oracle.Encrypt(want)
+		require.NoError(t, err)
+
+		attacker := NewPaddingOracleAttacker(oracle.ValidPadding, 16)
+		got, err := attacker.Decrypt(iv, enc)
+		require.NoError(t, err)
+		assert.Equal(t, string(want), string(got))
+		assert.LessOrEqual(t, attacker.Queries, 257*len(enc))
+		t.Logf("%d queries: %s", attacker.Queries, got)
+	}
+
+	t.Run("ambiguous 0x02 0x02", func(t *testing.T) {
+		// 14 bytes pads with 0x02 0x02, so the unmodified final byte is valid padding
+		want := []byte("fourteen bytes")
+		iv, enc, err := oracle.Encrypt(want)
+		require.NoError(t, err)
+
+		attacker := NewPaddingOracleAttacker(oracle.ValidPadding, 16)
+		got, err := attacker.Decrypt(iv, enc)
+		require.NoError(t, err)
+		assert.Equal(t, string(want), string(got))
+	})
+
+	t.Run("bad input", func(t *testing.T) {
+		attacker := NewPaddingOracleAttacker(oracle.ValidPadding, 16)
+		_, err := attacker.Decrypt(make([]byte, 16), make([]byte, 17))
+		require.Error(t, err)
+	})
+}
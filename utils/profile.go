@@ -0,0 +1,107 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidProfile = errors.New("invalid profile")
+
+// Profile is a user profile, encoded as a cookie like
+// email=foo@bar.com&uid=10&role=user
+type Profile struct {
+	Email string
+	UID   int
+	Role  string
+}
+
+// ProfileFor is the profile issued to a new user: uid 7, role user
+func ProfileFor(email string) *Profile {
+	return &Profile{Email: email, UID: 7, Role: "user"}
+}
+
+// profileFor encodes ProfileFor(email)
+func profileFor(email string) string {
+	return ProfileFor(email).Encode()
+}
+
+// Encode always writes email, uid, role in that order. & and = are
+// dropped from the email and role so they cannot inject fields
+func (p *Profile) Encode() string {
+	return fmt.Sprintf("email=%s&uid=%d&role=%s", eatRunes(p.Email), p.UID, eatRunes(p.Role))
+}
+
+// Parse fills p from an encoded profile. fields may come in any order but
+// each of email, uid and role must appear exactly once, and nothing else
+func (p *Profile) Parse(cookie string) error {
+	var parsed Profile
+	seen := make(map[string]bool)
+	for _, pair := range strings.Split(cookie, "&") {
+		parts := strings.Split(pair, "=")
+		if len(parts) != 2 {
+			return fmt.Errorf("%w: %q is not key=value", ErrInvalidProfile, pair)
+		}
+		k, v := parts[0], parts[1]
+		if k == "" {
+			return fmt.Errorf("%w: empty key in %q", ErrInvalidProfile, pair)
+		}
+		if seen[k] {
+			return fmt.Errorf("%w: duplicate key %q", ErrInvalidProfile, k)
+		}
+		seen[k] = true
+
+		switch k {
+		case "email":
+			parsed.Email = v
+		case "uid":
+			uid, err := strconv.Atoi(v)
+			if err != nil {
+				return fmt.Errorf("%w: uid %q is not a number", ErrInvalidProfile, v)
+			}
+			parsed.UID = uid
+		case "role":
+			parsed.Role = v
+		default:
+			return fmt.Errorf("%w: unknown key %q", ErrInvalidProfile, k)
+		}
+	}
+	for _, k := range []string{"email", "uid", "role"} {
+		if !seen[k] {
+			return fmt.Errorf("%w: missing %s", ErrInvalidProfile, k)
+		}
+	}
+	*p = parsed
+	return nil
+}
+
+// Encrypt issues p as an encrypted cookie
+func (p *Profile) Encrypt(c EncryptDecrypter) ([]byte, error) {
+	return c.Encrypt([]byte(p.Encode()))
+}
+
+// DecryptProfile validates an encrypted cookie made by Profile.Encrypt
+func DecryptProfile(c EncryptDecrypter, ciphertext []byte) (*Profile, error) {
+	txt, err := c.Decrypt(ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	p := &Profile{}
+	if err := p.Parse(string(txt)); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func eatRunes(s string) string {
+	var result string
+	for _, r := range s {
+		if r == '&' || r == '=' {
+			continue
+		} else {
+			result = result + string(r)
+		}
+	}
+	return result
+}
@@ -0,0 +1,84 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// EncryptDecrypter is an Encrypter that can also decrypt, such as AES
+type EncryptDecrypter interface {
+	Encrypter
+	Decrypt([]byte) ([]byte, error)
+}
+
+var ErrForgeryFailed = errors.New("forged profile does not have the requested role")
+
+// profileOracle encrypts the profile encode makes for local@domain, where
+// local is the attacker's input
+type profileOracle struct {
+	encode func(email string) string
+	ecb    EncryptDecrypter
+	domain string
+}
+
+func (o *profileOracle) Encrypt(local []byte) ([]byte, error) {
+	return o.ecb.Encrypt([]byte(o.encode(string(local) + "@" + o.domain)))
+}
+
+// ForgeProfileRole cuts and pastes ECB blocks into a profile for an address
+// at domain whose role is role. encode is profileFor or similar; the role
+// field must come last. the attack finds the block size and where the email
+// lands in the profile from the ciphertext, and where the role field starts
+// from encode. it only decrypts to check the forgery. role must fit in one block
+func ForgeProfileRole(encode func(email string) string, ecb EncryptDecrypter, domain, role string) ([]byte, error) {
+	oracle := &profileOracle{encode: encode, ecb: ecb, domain: domain}
+	blockSize, _, err := detectBlockSize(oracle)
+	if err != nil {
+		return nil, err
+	}
+	if len(role) >= blockSize {
+		return nil, fmt.Errorf("role %q does not fit in a %d byte block", role, blockSize)
+	}
+
+	// encrypt role || padding as a block of its own, at the start of the email
+	prefixLen, alignPad, err := DetectECBPrefix(oracle, blockSize)
+	if err != nil {
+		return nil, err
+	}
+	input := append(bytes.Repeat([]byte{'A'}, alignPad), PKCS7([]byte(role), blockSize)...)
+	enc, err := oracle.Encrypt(input)
+	if err != nil {
+		return nil, err
+	}
+	block := (prefixLen + alignPad) / blockSize
+	roleBlock := enc[block*blockSize : (block+1)*blockSize]
+
+	// find where role= ends with an empty local part. the encoder is ours to
+	// call, so the profile it encrypts needs no decryption
+	profile := encode("@" + domain)
+	idx := strings.LastIndex(profile, "role=")
+	if idx < 0 {
+		return nil, fmt.Errorf("no role field in %q", profile)
+	}
+	roleEnd := idx + len("role=")
+
+	// lengthen the email until role= ends a block, then replace the rest
+	n := (blockSize - roleEnd%blockSize) % blockSize
+	enc, err = oracle.Encrypt(bytes.Repeat([]byte{'A'}, n))
+	if err != nil {
+		return nil, err
+	}
+	forged := append(enc[:roleEnd+n:roleEnd+n], roleBlock...)
+
+	txt, err := ecb.Decrypt(forged)
+	if err != nil {
+		// the encoder changed the role block, so its padding is gone
+		return nil, fmt.Errorf("%w: %v", ErrForgeryFailed, err)
+	}
+	if !strings.HasSuffix(string(txt), "role="+role) {
+		return nil, fmt.Errorf("%w: got %q", ErrForgeryFailed, txt)
+	}
+	return forged, nil
+}
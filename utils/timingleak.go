@@ -0,0 +1,259 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+
+	"github.com/krehermann/go-cryptopals/sha1"
+)
+
+// TimingLeakServer is an http.Handler that accepts ?file=...&signature=...
+// when signature is the hex HMAC-SHA1 of file. the comparison exits early
+// and sleeps per matching byte, leaking how much of the signature is correct
+type TimingLeakServer struct {
+	key   []byte
+	delay time.Duration
+}
+
+func NewTimingLeakServer(key []byte, delay time.Duration) *TimingLeakServer {
+	return &TimingLeakServer{
+		key:   key,
+		delay: delay,
+	}
+}
+
+func (s *TimingLeakServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	file := r.URL.Query().Get("file")
+	sig, err := hex.DecodeString(r.URL.Query().Get("signature"))
+	if err != nil {
+		http.Error(w, "bad signature encoding", http.StatusBadRequest)
+		return
+	}
+	if !insecureCompare(HMACSHA1(s.key, []byte(file)), sig, s.delay) {
+		http.Error(w, "invalid signature", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func HMACSHA1(key, msg []byte) []byte {
+	m := hmac.New(func() hash.Hash { return sha1.New() }, key)
+	m.Write(msg)
+	return m.Sum(nil)
+}
+
+// insecureCompare is byte at a time with an early exit, and sleeps after each match
+func insecureCompare(want, got []byte, delay time.Duration) bool {
+	if len(want) != len(got) {
+		return false
+	}
+	for i := range want {
+		if want[i] != got[i] {
+			return false
+		}
+		time.Sleep(delay)
+	}
+	return true
+}
+
+// TimingAttacker recovers the signature of a file from a TimingLeakServer,
+// one byte at a time, by picking the guess that takes longest to reject
+type TimingAttacker struct {
+	URL    string
+	Client *http.Client
+	// Samples is the number of timings taken per guess
+	Samples int
+	// Filter reduces the timings of a guess to one, Median if nil
+	Filter SampleFilter
+	// Length is the number of signature bytes to recover, at most sha1.Size.
+	// sha1.Size if zero
+	Length int
+}
+
+// SampleFilter reduces repeated timings of one request to a single value
+type SampleFilter func([]time.Duration) time.Duration
+
+// Median ignores outliers in either direction
+func Median(d []time.Duration) time.Duration {
+	s := make([]time.Duration, len(d))
+	copy(s, d)
+	sort.Slice(s, func(i, j int) bool { return s[i] < s[j] })
+	return s[len(s)/2]
+}
+
+// Fastest suits hosts with frequent latency spikes: noise only ever makes a
+// request slower, so the fastest sample is the closest to the server's work
+func Fastest(d []time.Duration) time.Duration {
+	m := d[0]
+	for _, v := range d[1:] {
+		if v < m {
+			m = v
+		}
+	}
+	return m
+}
+
+// maxByteAttempts is how often a byte is guessed afresh when no guess
+// stands out, before RecoverSignature blames the byte before it
+const maxByteAttempts = 3
+
+// RecoverSignature returns the first Length bytes of the signature of file
+func (a *TimingAttacker) RecoverSignature(file string) ([]byte, error) {
+	samples := a.Samples
+	if samples < 1 {
+		samples = 1
+	}
+	if a.Length < 0 || a.Length > sha1.Size {
+		return nil, fmt.Errorf("length %d outside 0 to %d", a.Length, sha1.Size)
+	}
+	length := a.Length
+	if length == 0 {
+		length = sha1.Size
+	}
+	filter := a.Filter
+	if filter == nil {
+		filter = Median
+	}
+
+	sig := make([]byte, sha1.Size)
+	// leads[i] is how much slower byte i's winner was than the runner up.
+	// their median is about the server's per byte delay
+	leads := make([]time.Duration, sha1.Size)
+	backtracks := 0
+	for pos := 0; pos < length; pos++ {
+		if pos == sha1.Size-1 {
+			// the last byte doesn't need timing, the server tells us when we're right
+			full, err := a.bruteForceLast(file, sig)
+			if !errors.Is(err, errNoValidSignature) {
+				return full, err
+			}
+		}
+
+		var minLead time.Duration
+		if pos > 0 {
+			minLead = Median(leads[:pos]) / 2
+		}
+		found := false
+		for attempt := 0; attempt < maxByteAttempts && !found && pos < sha1.Size-1; attempt++ {
+			best, lead, err := a.guessByte(file, sig, pos, samples, filter)
+			if err != nil {
+				return nil, err
+			}
+			if lead > minLead {
+				sig[pos], leads[pos], found = byte(best), lead, true
+			}
+		}
+		if !found {
+			// when the bytes so far are right the correct guess is a full
+			// delay slower than the rest. nothing is, so go back one byte
+			if pos == 0 || backtracks == sha1.Size {
+				return nil, fmt.Errorf("no guess for byte %d stands out from the noise", pos)
+			}
+			backtracks++
+			pos -= 2
+		}
+	}
+	return sig[:length], nil
+}
+
+// guessByte times every value of sig[pos] and returns the slowest, and how
+// much slower it is than the runner up
+func (a *TimingAttacker) guessByte(file string, sig []byte, pos, samples int, filter SampleFilter) (int, time.Duration, error) {
+	filtered := make([]time.Duration, 256)
+	timings := make([][]time.Duration, 256)
+	// interleave the guesses so drift in the server affects all of them equally
+	for s := 0; s < samples; s++ {
+		for g := 0; g < 256; g++ {
+			sig[pos] = byte(g)
+			d, _, err := a.query(file, sig)
+			if err != nil {
+				return 0, 0, err
+			}
+			timings[g] = append(timings[g], d)
+		}
+	}
+	for g := range timings {
+		filtered[g] = filter(timings[g])
+	}
+
+	// successive halving: resample the leaders and drop the faster half
+	// each round, so the likeliest guesses collect the most samples
+	leaders := topN(filtered, 32)
+	for {
+		for s := 0; s < 2*samples; s++ {
+			for _, g := range leaders {
+				sig[pos] = byte(g)
+				d, _, err := a.query(file, sig)
+				if err != nil {
+					return 0, 0, err
+				}
+				timings[g] = append(timings[g], d)
+			}
+		}
+		for _, g := range leaders {
+			filtered[g] = filter(timings[g])
+		}
+		sort.Slice(leaders, func(i, j int) bool { return filtered[leaders[i]] > filtered[leaders[j]] })
+		if len(leaders) == 2 {
+			// both were sampled in the same rounds, so drift in the server
+			// cancels out of the gap between them
+			return leaders[0], filtered[leaders[0]] - filtered[leaders[1]], nil
+		}
+		leaders = leaders[:len(leaders)/2]
+	}
+}
+
+var errNoValidSignature = errors.New("no valid signature found")
+
+func (a *TimingAttacker) bruteForceLast(file string, sig []byte) ([]byte, error) {
+	for g := 0; g < 256; g++ {
+		sig[len(sig)-1] = byte(g)
+		_, status, err := a.query(file, sig)
+		if err != nil {
+			return nil, err
+		}
+		if status == http.StatusOK {
+			return sig, nil
+		}
+	}
+	return nil, fmt.Errorf("%w, an earlier byte is wrong: %x", errNoValidSignature, sig)
+}
+
+func (a *TimingAttacker) query(file string, sig []byte) (time.Duration, int, error) {
+	q := url.Values{}
+	q.Set("file", file)
+	q.Set("signature", hex.EncodeToString(sig))
+
+	client := a.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	start := time.Now()
+	resp, err := client.Get(a.URL + "?" + q.Encode())
+	if err != nil {
+		return 0, 0, err
+	}
+	elapsed := time.Since(start)
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	return elapsed, resp.StatusCode, nil
+}
+
+// topN returns the indexes of the n largest values
+func topN(d []time.Duration, n int) []int {
+	idx := make([]int, len(d))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.Slice(idx, func(i, j int) bool { return d[idx[i]] > d[idx[j]] })
+	return idx[:n]
+}
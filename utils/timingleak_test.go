@@ -0,0 +1,117 @@
+package utils
+
+import (
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimingLeakServer(t *testing.T) {
+	key := []byte("YELLOW SUBMARINE")
+	srv := httptest.NewServer(NewTimingLeakServer(key, time.Millisecond))
+	defer srv.Close()
+
+	file := "foo"
+	good := hex.EncodeToString(HMACSHA1(key, []byte(file)))
+
+	tests := []struct {
+		name string
+		sig  string
+		want int
+	}{
+		{name: "valid", sig: good, want: http.StatusOK},
+		{name: "invalid", sig: "00" + good[2:], want: http.StatusInternalServerError},
+		{name: "short", sig: good[:10], want: http.StatusInternalServerError},
+		{name: "not hex", sig: "zz", want: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := http.Get(srv.URL + "?file=" + file + "&signature=" + tt.sig)
+			require.NoError(t, err)
+			resp.Body.Close()
+			assert.Equal(t, tt.want, resp.StatusCode)
+		})
+	}
+}
+
+// set 4 challenges 31 and 32. recovering all 20 bytes takes minutes, so
+// these only recover a prefix. TestTimingAttacker_Full runs the whole attack
+func TestTimingAttacker_RecoverSignature(t *testing.T) {
+	key := []byte("YELLOW SUBMARINE")
+	file := "foo"
+	want := HMACSHA1(key, []byte(file))
+
+	tests := []struct {
+		name    string
+		delay   time.Duration
+		samples int
+		filter  SampleFilter
+		length  int
+	}{
+		{name: "5ms", delay: 5 * time.Millisecond, samples: 3, length: 2},
+		{name: "1ms", delay: time.Millisecond, samples: 5, length: 3},
+		{name: "1ms fastest", delay: time.Millisecond, samples: 3, filter: Fastest, length: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(NewTimingLeakServer(key, tt.delay))
+			defer srv.Close()
+
+			a := &TimingAttacker{
+				URL:     srv.URL,
+				Samples: tt.samples,
+				Filter:  tt.filter,
+				Length:  tt.length,
+			}
+			got, err := a.RecoverSignature(file)
+			require.NoError(t, err)
+			assert.Equal(t, want[:tt.length], got)
+		})
+	}
+}
+
+func TestTimingAttacker_BadLength(t *testing.T) {
+	for _, length := range []int{-1, 21} {
+		a := &TimingAttacker{URL: "http://127.0.0.1:0", Length: length}
+		_, err := a.RecoverSignature("foo")
+		require.Error(t, err, "length %d", length)
+	}
+}
+
+// set 4 challenge 32 end to end: the whole signature through a 1ms leak
+func TestTimingAttacker_Full(t *testing.T) {
+	if testing.Short() {
+		t.Skip("recovering a whole signature at 1ms per byte takes minutes")
+	}
+	key := []byte("YELLOW SUBMARINE")
+	file := "foo"
+	srv := httptest.NewServer(NewTimingLeakServer(key, time.Millisecond))
+	defer srv.Close()
+
+	a := &TimingAttacker{URL: srv.URL, Samples: 3}
+	got, err := a.RecoverSignature(file)
+	require.NoError(t, err)
+	assert.Equal(t, HMACSHA1(key, []byte(file)), got)
+}
+
+func TestTimingAttacker_Last(t *testing.T) {
+	key := []byte("YELLOW SUBMARINE")
+	file := "foo"
+	want := HMACSHA1(key, []byte(file))
+
+	// no delay needed to find the final byte
+	srv := httptest.NewServer(NewTimingLeakServer(key, 0))
+	defer srv.Close()
+
+	a := &TimingAttacker{URL: srv.URL}
+	sig := make([]byte, len(want))
+	copy(sig, want[:len(want)-1])
+	got, err := a.bruteForceLast(file, sig)
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+}
@@ -4,17 +4,14 @@ import (
 	"bytes"
 	"context"
 	"crypto/aes"
-	"crypto/cipher"
 	"crypto/rand"
 	"encoding/base64"
 	"encoding/hex"
 	"errors"
 	"fmt"
-	"log"
 	"math"
 	"math/big"
 	"math/bits"
-	"strings"
 
 	"github.com/pemistahl/lingua-go"
 )
@@ -176,20 +173,14 @@ func BlockDistance(data []byte, keyLen int, blocks int) (float64, error) {
 	return float64(sum) / (float64(blocks) * float64(keyLen)), nil
 }
 
+// AES is a BlockMode over crypto/aes
 type AES struct {
-	ciphr cipher.Block
-	mode  AESMode
-	// optional
-	cbcIV []byte
+	*BlockMode
 }
 
-type AESOpt func(*AES)
-
-func WithIV(iv []byte) AESOpt {
-	return func(a *AES) {
-		a.cbcIV = iv
-	}
-}
+// ErrKeyAsIV is returned for CBC given WithIV(key), which leaks the key to
+// anyone who sees a decryption. see KeyAsIVOracle
+var ErrKeyAsIV = errors.New("cbc iv must not equal the key")
 
 func NewAES(key []byte, mode AESMode, opts ...AESOpt) (*AES, error) {
 	c, err := aes.NewCipher(key)
@@ -197,83 +188,30 @@ func NewAES(key []byte, mode AESMode, opts ...AESOpt) (*AES, error) {
 		return nil, err
 	}
 
-	a := &AES{
-		ciphr: c,
-		mode:  mode,
-		cbcIV: make([]byte, len(key)),
-	}
-
-	for _, opt := range opts {
-		opt(a)
-	}
-	return a, nil
-}
-
-func (a *AES) Encrypt(src []byte) ([]byte, error) {
-	blockSize := a.ciphr.BlockSize()
-	padLen := len(src) % blockSize
-	if padLen != 0 {
-		padLen = blockSize - padLen
+	m, err := NewBlockMode(c, mode, opts...)
+	if err != nil {
+		return nil, err
 	}
-	src = PKCS7(src, len(src)+padLen)
-	result := make([]byte, len(src))
-
-	switch a.mode {
-	case AESECB:
-		for start, end := 0, blockSize; end <= len(src); start, end = start+blockSize, end+blockSize {
-			a.ciphr.Encrypt(result[start:end], src[start:end])
-		}
-	case AESCBC:
-		prevBlock := a.cbcIV
-		for start, end := 0, blockSize; end <= len(src); start, end = start+blockSize, end+blockSize {
-			transformed, err := FixedXor(prevBlock, src[start:end])
-			if err != nil {
-				return nil, err
-			}
-			a.ciphr.Encrypt(result[start:end], transformed)
-			prevBlock = result[start:end]
-		}
+	// the default zero iv is not the caller's choice, even for a zero key
+	if mode == AESCBC && m.ivSet && bytes.Equal(m.cbcIV, key) {
+		return nil, ErrKeyAsIV
 	}
-	return result, nil
-
-}
-
-func (a *AES) Decrypt(src []byte) ([]byte, error) {
-	result := make([]byte, len(src))
-	blockSize := a.ciphr.BlockSize()
-
-	switch a.mode {
-	case AESECB:
-		for start, end := 0, blockSize; end <= len(src); start, end = start+blockSize, end+blockSize {
-			a.ciphr.Decrypt(result[start:end], src[start:end])
-		}
-	case AESCBC:
-		prevBlock := a.cbcIV
-		tmp := make([]byte, a.ciphr.BlockSize())
-
-		for start, end := 0, blockSize; end <= len(src); start, end = start+blockSize, end+blockSize {
-			a.ciphr.Decrypt(tmp, src[start:end])
-
-			transformed, err := FixedXor(prevBlock, tmp)
-			if err != nil {
-				return nil, err
-			}
-			copy(result[start:end], transformed)
-			prevBlock = src[start:end]
-		}
-	}
-
-	// drop padding
-	return truncatePKCS7(result)
-
+	return &AES{BlockMode: m}, nil
 }
 
 type AESMode int
 
+// AESOracle encrypts under a random key and a randomly chosen mode, ECB or
+// CBC, each time it is called
 type AESOracle struct {
 	mode AESMode
 }
 
+// Mode is the mode used by the most recent call to Encrypt
+func (o *AESOracle) Mode() AESMode {
+	return o.mode
+}
+
 func (o *AESOracle) prepareForOracle(txt []byte) ([]byte, error) {
 	d := make([]byte, 0)
 
@@ -304,7 +242,6 @@ func (o *AESOracle) Encrypt(txt []byte) ([]byte, error) {
 		return nil, err
 	}
 	m := AESMode(v.Int64())
-	log.Printf("mode %d", m)
 
 	k := make([]byte, 16)
 	n, err := rand.Read(k)
@@ -362,6 +299,18 @@ func NewConsistentAESECB() (*ConsistentAESECB, error) {
 	}, nil
 }
 
+func randomBytes(n int) ([]byte, error) {
+	buf := make([]byte, n)
+	got, err := rand.Read(buf)
+	if err != nil {
+		return nil, err
+	}
+	if got != n {
+		return nil, fmt.Errorf("error generating random bytes, want %d got %d", n, got)
+	}
+	return buf, nil
+}
+
 func generateBytes(min, max int64) ([]byte, error) {
 	n, err := rand.Int(rand.Reader, big.NewInt(min+1))
 	if err != nil {
@@ -386,6 +335,7 @@ func generateBytes(min, max int64) ([]byte, error) {
 const (
 	AESECB AESMode = iota
 	AESCBC
+	AESCTR
 )
 
 func appendIfNotExists(appendable []int, toAppend ...int) []int {
@@ -445,10 +395,18 @@ func PKCS7(data []byte, padTo int) []byte {
 
 var ErrInvalidPKCS7 = errors.New("invalid PKCS7 padding")
 
-func truncatePKCS7(data []byte) ([]byte, error) {
+// truncatePKCS7 strips padding of 1 to blockSize bytes. anything longer
+// than a block is not padding PKCS7 could have produced
+func truncatePKCS7(data []byte, blockSize int) ([]byte, error) {
+	if len(data) == 0 {
+		return data, ErrInvalidPKCS7
+	}
 	// read last byte
 	x := data[len(data)-1]
 	v := int(x)
+	if v == 0 || v > blockSize || v > len(data) {
+		return data, ErrInvalidPKCS7
+	}
 
 	expected := make([]byte, v)
 	for i := 0; i < v; i++ {
@@ -508,82 +466,3 @@ func (o *AESECBOracle) Encrypt(txt []byte) ([]byte, error) {
 	d = append(d, o.hidden...)
 	return o.ConsistentAESECB.Encrypt(d)
 }
-
-type orderedKey struct {
-	position int
-	V        string
-}
-
-type CookieParser struct {
-	m map[string]orderedKey
-}
-
-func (c *CookieParser) Parse(cookie string) error {
-	if c.m == nil {
-		c.m = map[string]orderedKey{}
-	}
-	pairs := strings.Split(cookie, "&")
-	for i, pair := range pairs {
-		parts := strings.Split(pair, "=")
-		if len(parts) != 2 {
-			return fmt.Errorf("bad input %s at %s", cookie, pair)
-		}
-		c.m[parts[0]] = orderedKey{
-			position: i,
-			V:        parts[1]}
-	}
-	return nil
-}
-
-func (c *CookieParser) encode() string {
-	tempKV := make([]string, len(c.m))
-
-	for k, v := range c.m {
-		tempKV[v.position] = fmt.Sprintf("%s=%s", k, v.V)
-	}
-	return strings.Join(tempKV, "&")
-}
-
-type cookie struct {
-	email string
-	uid   int
-	role  string
-}
-
-func (c *cookie) encode() string {
-	var result string
-
-	result = fmt.Sprintf("email=%s", eatRunes(c.email))
-	result = fmt.Sprintf("%s&uid=%d", result, c.uid)
-	result = fmt.Sprintf("%s&role=%s", result, c.role)
-	return result
-
-}
-
-func eatRunes(s string) string {
-	var result string
-	for _, r := range s {
-		if r == '&' || r == '=' {
-			continue
-		} else {
-			result = result + string(r)
-		}
-	}
-	return result
-}
-
-func profileFor(email string) string {
-	/*
-		p := &CookieParser{}
-
-		p.Parse(eatRunes(email))
-
-		return p.encode()
-	*/
-	var result string
-
-	result = fmt.Sprintf("email=%s", eatRunes(email))
-	result = fmt.Sprintf("%s&uid=%d", result, 7)
-	result = fmt.Sprintf("%s&role=%s", result, "user")
-	return result
-}
@@ -4,10 +4,11 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"crypto/aes"
+	"crypto/cipher"
 	"crypto/rand"
 	"encoding/base64"
 	"encoding/hex"
-	"fmt"
 	"os"
 	"reflect"
 	"sort"
@@ -419,6 +420,100 @@ func TestAES(t *testing.T) {
 	})
 }
 
+func TestAES_Padding(t *testing.T) {
+	a, err := NewAES([]byte("YELLOW SUBMARINE"), AESECB)
+	require.NoError(t, err)
+	tests := []struct {
+		name   string
+		msg    []byte
+		encLen int
+	}{
+		{name: "empty", msg: []byte{}, encLen: 16},
+		{name: "unaligned", msg: []byte("hello"), encLen: 16},
+		{name: "aligned", msg: []byte("YELLOW SUBMARINE"), encLen: 32},
+		{name: "aligned ends like padding", msg: append(bytes.Repeat([]byte{'A'}, 15), 0x01), encLen: 32},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			enc, err := a.Encrypt(tt.msg)
+			require.NoError(t, err)
+			assert.Len(t, enc, tt.encLen)
+
+			// the padding is a whole block of 0x10 when the message is aligned
+			raw := make([]byte, 16)
+			a.ciphr.Decrypt(raw, enc[len(enc)-16:])
+			padLen := tt.encLen - len(tt.msg)
+			assert.Equal(t, bytes.Repeat([]byte{byte(padLen)}, padLen), raw[16-padLen:])
+
+			got, err := a.Decrypt(enc)
+			require.NoError(t, err)
+			assert.Equal(t, tt.msg, got)
+		})
+	}
+}
+
+func TestAESCTR(t *testing.T) {
+	key := []byte("YELLOW SUBMARINE")
+	// not a multiple of the block size
+	msg := []byte("CTR mode turns a block cipher into a stream cipher; no padding needed!!")
+
+	t.Run("set 3 challenge 18", func(t *testing.T) {
+		enc, err := base64.StdEncoding.DecodeString("L77na/nrFsKvynd6HzOoG7GHTLXsTVu9qvY/2syLXzhPweyyMTJULu/6/kXX0KSvoOLSFQ==")
+		require.NoError(t, err)
+		a, err := NewAES(key, AESCTR)
+		require.NoError(t, err)
+		txt, err := a.Decrypt(enc)
+		require.NoError(t, err)
+		assert.Equal(t, "Yo, VIP Let's kick it Ice, Ice, baby Ice, Ice, baby ", string(txt))
+	})
+
+	t.Run("big endian matches cipher.NewCTR", func(t *testing.T) {
+		nonce := []byte{0, 1, 2, 3, 4, 5, 6, 7}
+		a, err := NewAES(key, AESCTR, WithNonce(nonce), WithCounterLayout(BigEndianCounter))
+		require.NoError(t, err)
+		got, err := a.Encrypt(msg)
+		require.NoError(t, err)
+
+		block, err := aes.NewCipher(key)
+		require.NoError(t, err)
+		iv := make([]byte, aes.BlockSize)
+		copy(iv, nonce)
+		want := make([]byte, len(msg))
+		cipher.NewCTR(block, iv).XORKeyStream(want, msg)
+		assert.Equal(t, want, got)
+
+		dec, err := a.Decrypt(got)
+		require.NoError(t, err)
+		assert.Equal(t, msg, dec)
+	})
+
+	t.Run("little endian round trip", func(t *testing.T) {
+		a, err := NewAES(key, AESCTR, WithNonce([]byte("8 bytes!")))
+		require.NoError(t, err)
+		enc, err := a.Encrypt(msg)
+		require.NoError(t, err)
+		require.Len(t, enc, len(msg))
+
+		// the first counter block is the same for either layout
+		block, err := aes.NewCipher(key)
+		require.NoError(t, err)
+		iv := make([]byte, aes.BlockSize)
+		copy(iv, "8 bytes!")
+		want := make([]byte, aes.BlockSize)
+		cipher.NewCTR(block, iv).XORKeyStream(want, msg[:aes.BlockSize])
+		assert.Equal(t, want, enc[:aes.BlockSize])
+
+		dec, err := a.Decrypt(enc)
+		require.NoError(t, err)
+		assert.Equal(t, msg, dec)
+	})
+
+	t.Run("bad nonce", func(t *testing.T) {
+		_, err := NewAES(key, AESCTR, WithNonce([]byte("short")))
+		require.Error(t, err)
+	})
+}
+
 // set 2 challenge 11
 func TestAESOracle_Encrypt(t *testing.T) {
 
@@ -432,7 +527,7 @@ func TestAESOracle_Encrypt(t *testing.T) {
 	for i := 0; i < 16; i++ {
 		got, err := o.Encrypt([]byte(txt))
 		require.NoError(t, err)
-		if o.mode == AESECB {
+		if o.Mode() == AESECB {
 			assert.Equal(t, got[16:32], got[32:48])
 		}
 	}
@@ -447,273 +542,35 @@ YnkK`
 	cyphr, err := base64.RawStdEncoding.DecodeString(b4cyphrTxt)
 	require.NoError(t, err)
 
-	var maxBlockSize = 32
 	t.Run("no prefix", func(t *testing.T) {
-
 		oracle, err := NewAESECBOracle(cyphr, false)
 		require.NoError(t, err)
 
-		// determine the block size
-		// there are 3 possibilities 16, 24, 32
-
-		prefix := make([]byte, 2*maxBlockSize+1)
-		for j := 0; j < len(prefix); j++ {
-			prefix[j] = 'A'
-		}
-
-		var blockSize int
-		for i := 0; i < 3; i++ {
-			var l int
-			switch i {
-			case 0:
-				l = 16
-			case 1:
-				l = 24
-			case 2:
-				l = 32
-			default:
-				assert.FailNow(t, "block size search out of range")
-			}
-
-			d := prefix[:l]
-			d2 := prefix[:l+1]
-
-			enc, err := oracle.Encrypt(d)
-			require.NoError(t, err)
-
-			encRunner, err := oracle.Encrypt(d2)
-			require.NoError(t, err)
-
-			if bytes.Equal(enc[:l], encRunner[:l]) {
-				blockSize = l
-				break
-			}
-		}
-
-		assert.Equal(t, oracle.ciphr.BlockSize(), blockSize)
-
-		// confirm that the encryption is ecb. if we input a
-		// slice of len > 2*block  containing the same value, then
-		// the first two blocks will be equal under ECB
-
-		enc, err := oracle.Encrypt(prefix)
+		got, err := BreakECBSuffix(oracle)
 		require.NoError(t, err)
-		assert.True(t, bytes.Equal(enc[:blockSize], enc[blockSize:2*blockSize]))
-
-		// decode a byte a time
-		// make a plaintext of length blocksize
-		// fix the first blocksize -1
-		// for every possible value of the Nth byte, call the oracle with (fixed key + nth byte) and save the first block in a map
-		// call the oracle with fixed key, len N-1. compare the returned first block to the map
-
-		result := make([]byte, 0)
-		prependedResult := make([]byte, 0)
-		for i := 0; i < len(cyphr); i++ {
-
-			block := i / blockSize
-			//prepend a static block to result
-			prependedResult = append(prependedResult, prefix[:blockSize]...)
-			prependedResult = append(prependedResult, result...)
-
-			// the attack prefix is the last blockSize-1 bytes of the prepended result
-			attck := prependedResult[len(prependedResult)-(blockSize-1):]
-			require.Len(t, attck, blockSize-1)
-
-			solutions, err := generateAttackMap(oracle, attck, block, blockSize)
-			require.NoError(t, err)
-
-			// the bytes to send to the oracle input
-			// must pad such that our input + the hidden data (in cyphr)
-			// aligns so that the byte we are tried to decode
-			// is the last byte in a block.
-			// since `i` the length of the current result,
-			// we what the `ith` byte of the hidden cyphr data
-			// to be that last byte in a block
-
-			// padLen is the number of bytes needed to
-			// pre-pad the cyphr for it's ith byte to
-			// be the last byte in a block
-			padLen := (blockSize - 1) - (i % blockSize)
-			attackInput := prependedResult[:padLen]
-			require.Len(t, attackInput, padLen)
-			//hiddenInput := join(attackInput, cyphr)
-
-			got, err := oracle.Encrypt(attackInput)
-			require.NoError(t, err)
-			result, err = updateResult(result, got, blockSize, solutions)
-			require.NoError(t, err, "iter %d", i)
-
-		}
+		assert.Equal(t, oracle.ciphr.BlockSize(), got.BlockSize)
+		assert.Equal(t, 0, got.PrefixLen)
 		// we happen to know the ground truth plain text
-		want := string(cyphr)
-		require.Equal(t, want, string(result))
+		require.Equal(t, string(cyphr), string(got.Suffix))
 	})
 
 	t.Run("with prefix", func(t *testing.T) {
-
 		oracle, err := NewAESECBOracle(cyphr, true)
 		require.NoError(t, err)
 
-		// determine the block size
-		// there are 3 possibilities 16, 24, 32
-
-		prefix := make([]byte, 4*maxBlockSize+1)
-		for j := 0; j < len(prefix); j++ {
-			prefix[j] = 'A'
-		}
-
-		var blockSize int
-		var blockRepMap map[string][]int
-		var encryptedRes []byte
-		for i := 0; i < 3; i++ {
-			var l int
-			switch i {
-			case 0:
-				l = 16
-			case 1:
-				l = 24
-			case 2:
-				l = 32
-			default:
-				assert.FailNow(t, "block size search out of range")
-			}
-
-			// in general need n+1 blocks of known, repeative input to generate
-			// n consecutive hack blocks -- need one for padding
-			// this also serves to confirm that the oracle is using ECB
-			d := prefix[:3*l]
-
-			enc, err := oracle.Encrypt(d)
-			require.NoError(t, err)
-
-			_, r := DetectAES128ECB(enc, l)
-
-			if len(r) != 0 {
-				blockSize = l
-				blockRepMap = r
-				encryptedRes = enc
-				t.Logf("detected ECB %+v", r)
-				break
-			}
-
-		}
-
-		require.Equal(t, oracle.ciphr.BlockSize(), blockSize)
-
-		// we know the blocksize and the block index of the first repeating elements
-		// now determine the length of the hidden, random prefix so we can attack with
-		// the block-at-a-time strategy
-
-		require.Len(t, blockRepMap, 1)
-		var attackIdxs []int
-		for _, v := range blockRepMap {
-			attackIdxs = v
-		}
-		sort.Ints(attackIdxs)
-		startIdx := attackIdxs[0]
-		wanted := encryptedRes[0 : blockSize*(startIdx+1)]
-		padLen := -1
-		for i := 0; i < 2*blockSize; i++ {
-			pad := prefix[0:prefix[i]]
-			got, err := oracle.Encrypt(pad)
-			require.NoError(t, err)
-			if bytes.Equal(got[0:len(wanted)], wanted) {
-				padLen = i
-				break
-			}
-		}
-		require.True(t, padLen >= 0)
-		t.Logf("pad length %d", padLen)
-
-		hiddenPrefixLen := blockSize*(startIdx+1) - padLen
-		t.Logf("prefix len %d", hiddenPrefixLen)
-
-		// decode a byte a time
-		// make a plaintext of length blocksize
-		// fix the first blocksize -1
-		// for every possible value of the Nth byte, call the oracle with (fixed key + nth byte) and save the first block in a map
-		// call the oracle with fixed key, len N-1. compare the returned first block to the map
-
-		result := make([]byte, 0)
-		prependedResult := make([]byte, 0)
-		for i := 0; i < len(cyphr); i++ {
-
-			block := i / blockSize
-			//prepend a static block to result
-			prependedResult = append(prependedResult, prefix[:blockSize]...)
-			prependedResult = append(prependedResult, result...)
-
-			// the attack prefix is the last blockSize-1 bytes of the prepended result
-			attck := prependedResult[len(prependedResult)-(blockSize-1):]
-			require.Len(t, attck, blockSize-1)
-
-			solutions, err := generateAttackMap(oracle, attck, block, blockSize)
-			require.NoError(t, err)
-
-			// the bytes to send to the oracle input
-			// must pad such that our input + the hidden data (in cyphr)
-			// aligns so that the byte we are tried to decode
-			// is the last byte in a block.
-			// since `i` the length of the current result,
-			// we what the `ith` byte of the hidden cyphr data
-			// to be that last byte in a block
-
-			// padLen is the number of bytes needed to
-			// pre-pad the cyphr for it's ith byte to
-			// be the last byte in a block
-			padLen := (blockSize - 1) - (i % blockSize)
-			attackInput := prependedResult[:padLen]
-			require.Len(t, attackInput, padLen)
-			//hiddenInput := join(attackInput, cyphr)
-
-			got, err := oracle.Encrypt(attackInput)
-			require.NoError(t, err)
-			result, err = updateResult(result, got, blockSize, solutions)
-			require.NoError(t, err, "iter %d", i)
-
-		}
-		// we happen to know the ground truth plain text
-		want := string(cyphr)
-		require.Equal(t, want, string(result))
+		got, err := BreakECBSuffix(oracle)
+		require.NoError(t, err)
+		assert.Equal(t, oracle.ciphr.BlockSize(), got.BlockSize)
+		assert.Equal(t, len(oracle.prefix), got.PrefixLen)
+		require.Equal(t, string(cyphr), string(got.Suffix))
 	})
 
-}
-
-func generateAttackMap(oracle *AESECBOracle, attackBuf []byte, block, blockSize int) (map[string]byte, error) {
-
-	temp := make([]byte, len(attackBuf)+1)
-	copy(temp, attackBuf)
-
-	attackPos := len(temp) - 1
-	solutionMap := make(map[string]byte)
-	for i := 0; i <= 128; i++ {
-		val := byte(i)
-		temp[attackPos] = val
-		res, err := oracle.Encrypt(temp)
-
-		if err != nil {
-			return nil, err
-		}
-		res = res[:blockSize]
-		solutionMap[hex.EncodeToString(res)] = val
-	}
-	return solutionMap, nil
-}
-
-func updateResult(currentResult, cyphr []byte, blockSize int, solutionMap map[string]byte) ([]byte, error) {
-	block := len(currentResult) / blockSize
-	attackResult := cyphr[block*blockSize : (block+1)*blockSize]
-
-	s := hex.EncodeToString(attackResult)
-	decodeByte, exists := solutionMap[s]
-	if !exists {
-		return nil, fmt.Errorf("attack result %s not in solution map", s)
-	}
-
-	x := make([]byte, 0)
-	x = append(x, currentResult...)
-	x = append(x, decodeByte)
-	return x, nil
+	t.Run("not ecb", func(t *testing.T) {
+		a, err := NewAES([]byte("YELLOW SUBMARINE"), AESCBC)
+		require.NoError(t, err)
+		_, err = BreakECBSuffix(a)
+		require.ErrorIs(t, err, ErrNotECB)
+	})
 }
 
 func TestDetectAES128ECB(t *testing.T) {
@@ -777,37 +634,37 @@ func TestDetectAES128ECB(t *testing.T) {
 	}
 }
 
-func Test_cookie_encode(t *testing.T) {
-	type fields struct {
-		email string
-		uid   int
-		role  string
-	}
+func TestProfile_Encode(t *testing.T) {
 	tests := []struct {
-		name   string
-		fields fields
-		want   string
+		name    string
+		profile Profile
+		want    string
 	}{
 		{
-			name: "meta",
-			fields: fields{
-				email: "me@hack.com&role=admin",
-				uid:   3,
-				role:  "superman",
-			},
-			want: "email=me@hack.comroleadmin&uid=3&role=superman",
+			name:    "meta",
+			profile: Profile{Email: "me@hack.com&role=admin", UID: 3, Role: "superman"},
+			want:    "email=me@hack.comroleadmin&uid=3&role=superman",
+		},
+		{
+			name:    "role injection",
+			profile: Profile{Email: "x@y.com", UID: 1, Role: "user&role=admin"},
+			want:    "email=x@y.com&uid=1&role=userroleadmin",
+		},
+		{
+			name:    "profile for",
+			profile: *ProfileFor("foo@bar.com"),
+			want:    "email=foo@bar.com&uid=7&role=user",
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			c := &cookie{
-				email: tt.fields.email,
-				uid:   tt.fields.uid,
-				role:  tt.fields.role,
-			}
-			if got := c.encode(); got != tt.want {
-				t.Errorf("cookie.encode() = %v, want %v", got, tt.want)
-			}
+			got := tt.profile.Encode()
+			assert.Equal(t, tt.want, got)
+
+			// whatever Encode writes, Parse reads back
+			var p Profile
+			require.NoError(t, p.Parse(got))
+			assert.Equal(t, got, p.Encode())
 		})
 	}
 }
@@ -833,74 +690,102 @@ func TestAESECDRoundTrip(t *testing.T) {
 	require.Equal(t, string(got), m)
 }
 
+// set 2 challenge 13
 func TestSetC13(t *testing.T) {
-	// with a user selected email we end with an ecb encrypted value of
-	// `email=foo@bar.com&uid=10&role=user` and want to make this into and admin profile
-	// we control the beginning, ie the email and want to change the end
-	// bc we control the beginning we can make a block that ends with `role=` by
-	// choosing an appropriate len email address
-	// then we would need to copy and paste the encrypted value of `admin` in the last block
-	// how do we get that??
-	// the trick is assuming or knowing that pkcs7 padding in the last block
-	// with that assumption, you can craft another email=.. prefix that is the block len and
-	// append pksc7(admin) to it, so that the 2nd block is the encrypted value of admin
-
-	// len of email to push `role` to last block
-	blockSize := 16
-	domain := "abc.com"
-	emailLen := blockSize - (len("email=")+len(fmt.Sprintf("@%s", domain))+len("&uid=7&role="))%blockSize
-
-	var userName string
-	for i := 0; i < emailLen; i++ {
-		userName += "X"
-	}
-
-	addr := fmt.Sprintf("%s@%s", userName, domain)
-
-	p := profileFor(addr)
-
 	k := make([]byte, 16)
 	n, err := rand.Read(k)
 	require.NoError(t, err)
 	require.Equal(t, n, 16)
 	oracle, err := NewAES(k, AESECB)
 	require.NoError(t, err)
-	truthVal, err := oracle.Encrypt([]byte(p))
-	require.NoError(t, err)
 
-	hackLen := blockSize - (len("email=")+len(fmt.Sprintf("@%s", domain)))%blockSize
-	var hackUserName string
-	for i := 0; i < hackLen; i++ {
-		hackUserName += "Q"
+	tests := []struct {
+		domain string
+		role   string
+	}{
+		{domain: "abc.com", role: "admin"},
+		{domain: "bar.com", role: "root"},
+		{domain: "a.very.long.subdomain.example.org", role: "superuser"},
+		{domain: "x.io", role: "fifteen-chars!!"},
+		{domain: "", role: "admin"},
 	}
-	hackEmail := fmt.Sprintf("%s@%s", hackUserName, domain)
-	adminBlock := PKCS7([]byte("admin"), blockSize)
+	for _, tt := range tests {
+		t.Run(tt.domain+" "+tt.role, func(t *testing.T) {
+			forged, err := ForgeProfileRole(profileFor, oracle, tt.domain, tt.role)
+			require.NoError(t, err)
 
-	//hackInput := make([]byte, 0)
-	hackInput := []byte(hackEmail)
-	hackInput = append(hackInput, adminBlock...)
+			adminProfile, err := oracle.Decrypt(forged)
+			require.NoError(t, err)
+			t.Logf("%s", adminProfile)
+			require.True(t, strings.HasSuffix(string(adminProfile), "&role="+tt.role))
+			require.True(t, strings.HasPrefix(string(adminProfile), "email="))
+		})
+	}
 
-	hackProfile := profileFor(string(hackInput))
-	hackVal, err := oracle.Encrypt([]byte(hackProfile))
-	require.NoError(t, err)
+	t.Run("role too long", func(t *testing.T) {
+		_, err := ForgeProfileRole(profileFor, oracle, "abc.com", "sixteen-chars!!!")
+		require.Error(t, err)
+	})
 
-	cutAndPasteVal := hackVal[blockSize : 2*blockSize]
+	t.Run("role mangled by encoder", func(t *testing.T) {
+		_, err := ForgeProfileRole(profileFor, oracle, "abc.com", "a&b")
+		require.ErrorIs(t, err, ErrForgeryFailed)
+	})
+}
+func TestProfile_Parse(t *testing.T) {
+	tests := []struct {
+		name    string
+		cookie  string
+		want    Profile
+		wantErr bool
+	}{
+		{name: "ok", cookie: "email=x@y.com&uid=1&role=user", want: Profile{Email: "x@y.com", UID: 1, Role: "user"}},
+		{name: "any order", cookie: "role=admin&email=x@y.com&uid=10", want: Profile{Email: "x@y.com", UID: 10, Role: "admin"}},
+		{name: "empty values", cookie: "email=&uid=0&role=", want: Profile{}},
+		{name: "duplicate key", cookie: "email=x@y.com&uid=1&role=user&role=admin", wantErr: true},
+		{name: "empty key", cookie: "email=x@y.com&uid=1&role=user&=admin", wantErr: true},
+		{name: "value with =", cookie: "email=x@y.com&uid=1&role=user=admin", wantErr: true},
+		{name: "no =", cookie: "email=x@y.com&uid=1&role", wantErr: true},
+		{name: "unknown key", cookie: "email=x@y.com&uid=1&role=user&admin=true", wantErr: true},
+		{name: "missing field", cookie: "email=x@y.com&uid=1", wantErr: true},
+		{name: "bad uid", cookie: "email=x@y.com&uid=one&role=user", wantErr: true},
+		{name: "empty", cookie: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := Profile{Email: "unchanged"}
+			err := p.Parse(tt.cookie)
+			if tt.wantErr {
+				require.ErrorIs(t, err, ErrInvalidProfile)
+				assert.Equal(t, "unchanged", p.Email)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, p)
+		})
+	}
+}
 
-	hacked := truthVal[:len(truthVal)-blockSize]
-	hacked = append(hacked, cutAndPasteVal...)
+func TestProfile_Encrypt(t *testing.T) {
+	a, err := NewAES([]byte("YELLOW SUBMARINE"), AESECB)
+	require.NoError(t, err)
 
-	adminProfile, err := oracle.Decrypt(hacked)
+	p := ProfileFor("foo@bar.com")
+	enc, err := p.Encrypt(a)
 	require.NoError(t, err)
-	t.Logf("%s", adminProfile)
-	require.True(t, strings.HasSuffix(string(adminProfile), "role=admin"))
-}
-func TestCookieParser(t *testing.T) {
-	p := &CookieParser{}
-	want := "email=x@y.com&uid=1&role=user"
-	err := p.Parse("email=x@y.com&uid=1&role=user")
+	got, err := DecryptProfile(a, enc)
+	require.NoError(t, err)
+	assert.Equal(t, p, got)
+
+	// the cut and paste forgery produces a valid profile
+	forged, err := ForgeProfileRole(profileFor, a, "bar.com", "admin")
 	require.NoError(t, err)
-	got := p.encode()
-	require.Equal(t, want, got)
+	got, err = DecryptProfile(a, forged)
+	require.NoError(t, err)
+	assert.Equal(t, "admin", got.Role)
+
+	_, err = DecryptProfile(a, enc[:len(enc)-1])
+	require.Error(t, err)
 }
 
 func Test_truncatePKCS7(t *testing.T) {
@@ -931,10 +816,45 @@ func Test_truncatePKCS7(t *testing.T) {
 			wantErr: true,
 			want:    []byte{'a', 'a', 'a', 'a', 'a', 'a', 'a', 'a', 'a', 'a', 'a', 'a', 'a', 'a', 1, 2},
 		},
+		{
+			name: "zero pad byte",
+			args: args{
+				data:      []byte{'a', 'a', 'a', 'a', 'a', 'a', 'a', 'a', 'a', 'a', 'a', 'a', 'a', 'a', 'a', 0},
+				blockSize: 16,
+			},
+			wantErr: true,
+			want:    []byte{'a', 'a', 'a', 'a', 'a', 'a', 'a', 'a', 'a', 'a', 'a', 'a', 'a', 'a', 'a', 0},
+		},
+		{
+			name: "pad longer than data",
+			args: args{
+				data:      []byte{'a', 5, 5},
+				blockSize: 16,
+			},
+			wantErr: true,
+			want:    []byte{'a', 5, 5},
+		},
+		{
+			name: "pad longer than a block",
+			args: args{
+				data:      append([]byte{'a'}, bytes.Repeat([]byte{17}, 17)...),
+				blockSize: 16,
+			},
+			wantErr: true,
+			want:    append([]byte{'a'}, bytes.Repeat([]byte{17}, 17)...),
+		},
+		{
+			name: "full block of padding",
+			args: args{
+				data:      append([]byte("YELLOW SUBMARINE"), bytes.Repeat([]byte{16}, 16)...),
+				blockSize: 16,
+			},
+			want: []byte("YELLOW SUBMARINE"),
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := truncatePKCS7(tt.args.data)
+			got, err := truncatePKCS7(tt.args.data, tt.args.blockSize)
 			if !tt.wantErr {
 				require.NoError(t, err)
 